@@ -69,6 +69,7 @@ func main() {
 	}
 	fmt.Printf("QR Code ID: %s\n", qrCode.ID)
 	fmt.Printf("QR Link: %s\n", qrCode.QRLink)
+	fmt.Printf("QR Image: %s, %d bytes\n", qrCode.QRCodeMIMEType, len(qrCode.QRCodeBytes))
 
 	// Get all tags
 	fmt.Println("\nGetting all tags...")
@@ -87,6 +88,9 @@ func main() {
 		log.Fatal(err)
 	}
 	fmt.Printf("Total clicks: %d\n", stats.Clicks.Total)
+	for _, referrer := range stats.TopReferrers(3) {
+		fmt.Printf("Referrer: %s (%d clicks)\n", referrer.URL, referrer.Total)
+	}
 
 	// Clean up - delete QR code and short code
 	fmt.Println("\nCleaning up...")
@@ -40,7 +40,11 @@ func main() {
 		log.Fatal(err)
 	}
 
-	for i, info := range ipInfos {
-		fmt.Printf("\nIP %d: %+v\n", i+1, info)
+	for _, result := range ipInfos {
+		if err := result.Err(); err != nil {
+			fmt.Printf("\n%s: %v\n", result.IP, err)
+			continue
+		}
+		fmt.Printf("\n%s: %s, %s\n", result.IP, result.Info.Location.City, result.Info.Location.Country)
 	}
 }
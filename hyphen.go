@@ -3,6 +3,10 @@
 package hyphen
 
 import (
+	"log/slog"
+	"net/http"
+	"time"
+
 	"github.com/Hyphen/go-sdk/pkg/env"
 	"github.com/Hyphen/go-sdk/pkg/link"
 	"github.com/Hyphen/go-sdk/pkg/netinfo"
@@ -16,18 +20,36 @@ type Options struct {
 	PublicAPIKey string // Public API key for Toggle service
 
 	// Toggle options
-	ApplicationID       string          // Application ID for Toggle
-	Environment         string          // Environment for Toggle (defaults to "development")
-	DefaultContext      *toggle.Context // Default context for Toggle evaluations
-	HorizonURLs         []string        // Custom Horizon URLs for Toggle
-	DefaultTargetingKey string          // Default targeting key for Toggle
+	ApplicationID        string          // Application ID for Toggle
+	Environment          string          // Environment for Toggle (defaults to "development")
+	DefaultContext       *toggle.Context // Default context for Toggle evaluations
+	HorizonURLs          []string        // Custom Horizon URLs for Toggle
+	DefaultTargetingKey  string          // Default targeting key for Toggle
+	ToggleRateLimit      float64         // Requests per second for Toggle service
+	ToggleRateLimitBurst int             // Rate limit burst size for Toggle service
+	ToggleMaxInFlight    int             // Maximum concurrent Toggle requests
 
 	// NetInfo options
-	NetInfoBaseURI string // Base URI for NetInfo service
+	NetInfoBaseURI        string                // Base URI for NetInfo service
+	NetInfoRateLimit      float64               // Requests per second for NetInfo service
+	NetInfoRateLimitBurst int                   // Rate limit burst size for NetInfo service
+	NetInfoMaxInFlight    int                   // Maximum concurrent NetInfo requests
+	NetInfoCache          *netinfo.CacheOptions // In-memory cache for NetInfo lookups, nil disables it
 
 	// Link options
-	OrganizationID string   // Organization ID for Link service
-	LinkURIs       []string // Custom URIs for Link service
+	OrganizationID       string            // Organization ID for Link service
+	LinkDefaultDomain    string            // Domain used when CreateShortCode gets none
+	LinkURIs             []string          // Custom URIs for Link service, tried in order
+	LinkURIVariables     map[string]string // Values for placeholders such as {region} in LinkURIs
+	LinkFailoverCooldown time.Duration     // How long a failing Link URI is skipped
+	LinkRateLimit        float64           // Requests per second for Link service
+	LinkRateLimitBurst   int               // Rate limit burst size for Link service
+	LinkMaxInFlight      int               // Maximum concurrent Link requests
+
+	// Shared options
+	Logger          *slog.Logger // Structured logger shared by all services
+	MaxResponseSize int64        // Maximum decompressed response size for all services
+	HTTPClient      *http.Client // HTTP client shared by all services
 }
 
 // Option is a functional option for configuring Hyphen services
@@ -96,13 +118,108 @@ func WithNetInfoBaseURI(uri string) Option {
 	}
 }
 
-// WithLinkURIs sets custom URIs for the Link service
+// WithLinkURIs sets custom URIs for the Link service. Requests fail over to
+// the next URI on network errors and 5xx responses.
 func WithLinkURIs(uris []string) Option {
 	return func(o *Options) {
 		o.LinkURIs = uris
 	}
 }
 
+// WithLinkDefaultDomain sets the domain used for short codes created without one
+func WithLinkDefaultDomain(domain string) Option {
+	return func(o *Options) {
+		o.LinkDefaultDomain = domain
+	}
+}
+
+// WithLinkURIVariables sets values for placeholders in the Link URIs, such as {region}
+func WithLinkURIVariables(vars map[string]string) Option {
+	return func(o *Options) {
+		o.LinkURIVariables = vars
+	}
+}
+
+// WithLinkFailoverCooldown sets how long a failing Link URI is skipped
+func WithLinkFailoverCooldown(d time.Duration) Option {
+	return func(o *Options) {
+		o.LinkFailoverCooldown = d
+	}
+}
+
+// WithToggleRateLimit limits Toggle requests to rps per second with bursts of up to burst
+func WithToggleRateLimit(rps float64, burst int) Option {
+	return func(o *Options) {
+		o.ToggleRateLimit = rps
+		o.ToggleRateLimitBurst = burst
+	}
+}
+
+// WithToggleMaxInFlight limits the number of concurrent Toggle requests
+func WithToggleMaxInFlight(n int) Option {
+	return func(o *Options) {
+		o.ToggleMaxInFlight = n
+	}
+}
+
+// WithNetInfoRateLimit limits NetInfo requests to rps per second with bursts of up to burst
+func WithNetInfoRateLimit(rps float64, burst int) Option {
+	return func(o *Options) {
+		o.NetInfoRateLimit = rps
+		o.NetInfoRateLimitBurst = burst
+	}
+}
+
+// WithNetInfoCache caches NetInfo lookups in memory
+func WithNetInfoCache(cache netinfo.CacheOptions) Option {
+	return func(o *Options) {
+		o.NetInfoCache = &cache
+	}
+}
+
+// WithNetInfoMaxInFlight limits the number of concurrent NetInfo requests
+func WithNetInfoMaxInFlight(n int) Option {
+	return func(o *Options) {
+		o.NetInfoMaxInFlight = n
+	}
+}
+
+// WithLinkRateLimit limits Link requests to rps per second with bursts of up to burst
+func WithLinkRateLimit(rps float64, burst int) Option {
+	return func(o *Options) {
+		o.LinkRateLimit = rps
+		o.LinkRateLimitBurst = burst
+	}
+}
+
+// WithLinkMaxInFlight limits the number of concurrent Link requests
+func WithLinkMaxInFlight(n int) Option {
+	return func(o *Options) {
+		o.LinkMaxInFlight = n
+	}
+}
+
+// WithLogger sets the structured logger shared by all services
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithMaxResponseSize limits the decompressed size of response bodies for all services
+func WithMaxResponseSize(n int64) Option {
+	return func(o *Options) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithHTTPClient sets the *http.Client used by all services
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = httpClient
+	}
+}
+
 // Re-export main types for convenience
 type (
 	// Toggle types
@@ -112,8 +229,16 @@ type (
 	ToggleCustomAttrs = toggle.CustomAttributes
 
 	// NetInfo types
-	NetInfo = netinfo.NetInfo
-	IPInfo  = netinfo.IPInfo
+	NetInfo           = netinfo.NetInfo
+	IPInfo            = netinfo.IPInfo
+	IPInfoError       = netinfo.IPInfoError
+	IPInfoResult      = netinfo.IPInfoResult
+	IPInfoResults     = netinfo.IPInfoResults
+	BulkOptions       = netinfo.BulkOptions
+	BulkResult        = netinfo.BulkResult
+	CacheOptions      = netinfo.CacheOptions
+	CacheStats        = netinfo.CacheStats
+	MiddlewareOptions = netinfo.MiddlewareOptions
 
 	// Link types
 	Link                   = link.Link
@@ -125,6 +250,54 @@ type (
 	GetShortCodesResponse  = link.GetShortCodesResponse
 	GetQRCodesResponse     = link.GetQRCodesResponse
 	GetCodeStatsResponse   = link.GetCodeStatsResponse
+	ClicksStats            = link.ClicksStats
+	ClicksByDay            = link.ClicksByDay
+	ClickTotals            = link.ClickTotals
+	ClicksPeriod           = link.ClicksPeriod
+	ClicksComparison       = link.ClicksComparison
+	ShortCodesOptions      = link.ShortCodesOptions
+	QRCodesOptions         = link.QRCodesOptions
+	ShortCodeRequest       = link.ShortCodeRequest
+	BatchOptions           = link.BatchOptions
+	BatchResults           = link.BatchResults
+	BatchError             = link.BatchError
+	ImportOptions          = link.ImportOptions
+	ImportReport           = link.ImportReport
+	ExportOptions          = link.ExportOptions
+	URIHealth              = link.URIHealth
+	QRImageFormat          = link.QRImageFormat
+	ValidationError        = link.ValidationError
+	FieldError             = link.FieldError
+	Campaign               = link.Campaign
+	EnsureResult           = link.EnsureResult
+	EnsureAction           = link.EnsureAction
+	InspectOptions         = link.InspectOptions
+	Resolution             = link.Resolution
+	Hop                    = link.Hop
+	CheckOptions           = link.CheckOptions
+	LinkCheck              = link.LinkCheck
+	LinkChecks             = link.LinkChecks
+	TagOptions             = link.TagOptions
+	TagChange              = link.TagChange
+	TagReport              = link.TagReport
+	TagUsage               = link.TagUsage
+	AnalyticsOptions       = link.AnalyticsOptions
+	AnalyticsRow           = link.AnalyticsRow
+	AnalyticsState         = link.AnalyticsState
+	SweepOptions           = link.SweepOptions
+	SweepResult            = link.SweepResult
+	SweepReport            = link.SweepReport
+	WebhookHandler         = link.WebhookHandler
+	WebhookOption          = link.WebhookOption
+	WebhookVerifier        = link.WebhookVerifier
+	WebhookVerifierFunc    = link.WebhookVerifierFunc
+	HMACVerifier           = link.HMACVerifier
+	WebhookEvent           = link.WebhookEvent
+	WebhookEventType       = link.WebhookEventType
+	ClickEvent             = link.ClickEvent
+	CodeCreatedEvent       = link.CodeCreatedEvent
+	CodeUpdatedEvent       = link.CodeUpdatedEvent
+	CodeDeletedEvent       = link.CodeDeletedEvent
 
 	// EnvOptions for environment variable loading
 	EnvOptions = env.EnvOptions
@@ -163,6 +336,8 @@ func New(options ...Option) (*Client, error) {
 		n, err := NewNetInfo(options...)
 		if err == nil {
 			client.NetInfo = n
+		} else if opts.Logger != nil {
+			opts.Logger.Warn("netinfo service disabled", slog.Any("error", err))
 		}
 	}
 
@@ -171,6 +346,8 @@ func New(options ...Option) (*Client, error) {
 		l, err := NewLink(options...)
 		if err == nil {
 			client.Link = l
+		} else if opts.Logger != nil {
+			opts.Logger.Warn("link service disabled", slog.Any("error", err))
 		}
 	}
 
@@ -204,6 +381,21 @@ func NewToggle(options ...Option) (*toggle.Toggle, error) {
 	if opts.DefaultTargetingKey != "" {
 		toggleOpts = append(toggleOpts, toggle.WithDefaultTargetingKey(opts.DefaultTargetingKey))
 	}
+	if opts.ToggleRateLimit > 0 {
+		toggleOpts = append(toggleOpts, toggle.WithRateLimit(opts.ToggleRateLimit, opts.ToggleRateLimitBurst))
+	}
+	if opts.ToggleMaxInFlight > 0 {
+		toggleOpts = append(toggleOpts, toggle.WithMaxInFlight(opts.ToggleMaxInFlight))
+	}
+	if opts.Logger != nil {
+		toggleOpts = append(toggleOpts, toggle.WithLogger(opts.Logger))
+	}
+	if opts.MaxResponseSize != 0 {
+		toggleOpts = append(toggleOpts, toggle.WithMaxResponseSize(opts.MaxResponseSize))
+	}
+	if opts.HTTPClient != nil {
+		toggleOpts = append(toggleOpts, toggle.WithHTTPClient(opts.HTTPClient))
+	}
 
 	return toggle.New(toggleOpts...)
 }
@@ -223,6 +415,24 @@ func NewNetInfo(options ...Option) (*netinfo.NetInfo, error) {
 	if opts.NetInfoBaseURI != "" {
 		netinfoOpts = append(netinfoOpts, netinfo.WithBaseURI(opts.NetInfoBaseURI))
 	}
+	if opts.NetInfoRateLimit > 0 {
+		netinfoOpts = append(netinfoOpts, netinfo.WithRateLimit(opts.NetInfoRateLimit, opts.NetInfoRateLimitBurst))
+	}
+	if opts.NetInfoMaxInFlight > 0 {
+		netinfoOpts = append(netinfoOpts, netinfo.WithMaxInFlight(opts.NetInfoMaxInFlight))
+	}
+	if opts.NetInfoCache != nil {
+		netinfoOpts = append(netinfoOpts, netinfo.WithCache(*opts.NetInfoCache))
+	}
+	if opts.Logger != nil {
+		netinfoOpts = append(netinfoOpts, netinfo.WithLogger(opts.Logger))
+	}
+	if opts.MaxResponseSize != 0 {
+		netinfoOpts = append(netinfoOpts, netinfo.WithMaxResponseSize(opts.MaxResponseSize))
+	}
+	if opts.HTTPClient != nil {
+		netinfoOpts = append(netinfoOpts, netinfo.WithHTTPClient(opts.HTTPClient))
+	}
 
 	return netinfo.New(netinfoOpts...)
 }
@@ -242,9 +452,33 @@ func NewLink(options ...Option) (*link.Link, error) {
 	if opts.OrganizationID != "" {
 		linkOpts = append(linkOpts, link.WithOrganizationID(opts.OrganizationID))
 	}
+	if opts.LinkDefaultDomain != "" {
+		linkOpts = append(linkOpts, link.WithDefaultDomain(opts.LinkDefaultDomain))
+	}
 	if len(opts.LinkURIs) > 0 {
 		linkOpts = append(linkOpts, link.WithURIs(opts.LinkURIs))
 	}
+	if len(opts.LinkURIVariables) > 0 {
+		linkOpts = append(linkOpts, link.WithURIVariables(opts.LinkURIVariables))
+	}
+	if opts.LinkFailoverCooldown > 0 {
+		linkOpts = append(linkOpts, link.WithFailoverCooldown(opts.LinkFailoverCooldown))
+	}
+	if opts.LinkRateLimit > 0 {
+		linkOpts = append(linkOpts, link.WithRateLimit(opts.LinkRateLimit, opts.LinkRateLimitBurst))
+	}
+	if opts.LinkMaxInFlight > 0 {
+		linkOpts = append(linkOpts, link.WithMaxInFlight(opts.LinkMaxInFlight))
+	}
+	if opts.Logger != nil {
+		linkOpts = append(linkOpts, link.WithLogger(opts.Logger))
+	}
+	if opts.MaxResponseSize != 0 {
+		linkOpts = append(linkOpts, link.WithMaxResponseSize(opts.MaxResponseSize))
+	}
+	if opts.HTTPClient != nil {
+		linkOpts = append(linkOpts, link.WithHTTPClient(opts.HTTPClient))
+	}
 
 	return link.New(linkOpts...)
 }
@@ -252,9 +486,67 @@ func NewLink(options ...Option) (*link.Link, error) {
 // LoadEnv loads environment variables from .env files
 var LoadEnv = env.LoadEnv
 
+// GenerateQRCode renders a QR code for a short code locally
+var GenerateQRCode = link.GenerateQRCode
+
+// ErrShortCodeConflict is returned by EnsureShortCode when a custom code is
+// taken by another long URL
+var ErrShortCodeConflict = link.ErrShortCodeConflict
+
+// ErrTooManyRedirects is returned when a redirect chain is longer than allowed
+var ErrTooManyRedirects = link.ErrTooManyRedirects
+
+// ExpiryTag returns the tag that records when a short code should expire
+var ExpiryTag = link.ExpiryTag
+
+// Geo information for incoming HTTP requests
+var (
+	ClientIP            = netinfo.ClientIP
+	IPInfoFromContext   = netinfo.IPInfoFromContext
+	ClientIPFromContext = netinfo.ClientIPFromContext
+	ContextWithIPInfo   = netinfo.ContextWithIPInfo
+)
+
+// Receiving Link webhooks
+var (
+	NewWebhookHandler      = link.NewWebhookHandler
+	SignWebhook            = link.SignWebhook
+	NewHMACVerifier        = link.NewHMACVerifier
+	WithWebhookMaxBodySize = link.WithWebhookMaxBodySize
+	WithWebhookLogger      = link.WithWebhookLogger
+	ErrWebhookSignature    = link.ErrWebhookSignature
+	ErrWebhookTimestamp    = link.ErrWebhookTimestamp
+)
+
+// Validation of Link requests without sending them
+var (
+	ValidateShortCode       = link.ValidateShortCode
+	ValidateShortCodeUpdate = link.ValidateShortCodeUpdate
+	ValidateQRCodeOptions   = link.ValidateQRCodeOptions
+)
+
 // Re-export constants
 const (
 	QRSizeSmall  = link.QRSizeSmall
 	QRSizeMedium = link.QRSizeMedium
 	QRSizeLarge  = link.QRSizeLarge
+
+	FormatCSV       = link.FormatCSV
+	FormatJSON      = link.FormatJSON
+	FormatJSONLines = link.FormatJSONLines
+
+	QRImagePNG = link.QRImagePNG
+	QRImageSVG = link.QRImageSVG
+
+	EnsureCreated   = link.EnsureCreated
+	EnsureUpdated   = link.EnsureUpdated
+	EnsureUnchanged = link.EnsureUnchanged
+
+	SweepDeleted    = link.SweepDeleted
+	SweepRetargeted = link.SweepRetargeted
+
+	EventLinkClicked = link.EventLinkClicked
+	EventCodeCreated = link.EventCodeCreated
+	EventCodeUpdated = link.EventCodeUpdated
+	EventCodeDeleted = link.EventCodeDeleted
 )
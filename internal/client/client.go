@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -27,28 +28,105 @@ type Response struct {
 	Body       []byte
 }
 
+// StreamResponse wraps an HTTP response whose body has not been read yet. The
+// body is transparently decompressed and bounded by the client's maximum
+// response size.
+type StreamResponse struct {
+	StatusCode int
+	Status     string
+	Headers    http.Header
+	Body       io.ReadCloser
+}
+
 // Client is the base HTTP client for the SDK
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient      *http.Client
+	baseURL         string
+	logger          *slog.Logger
+	limiter         *RateLimiter
+	inFlight        chan struct{}
+	maxResponseSize int64
 }
 
-// NewClient creates a new HTTP client
-func NewClient(baseURL string) *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		baseURL: baseURL,
+// ClientOption is a functional option for configuring the base HTTP client
+type ClientOption func(*Client)
+
+// WithLogger sets the logger used for request lifecycle events
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithHTTPClient replaces the underlying *http.Client. A nil client keeps the
+// default.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithRateLimit limits requests to rps per second with bursts of up to burst
+// requests. Requests wait for capacity instead of being sent and rejected, and
+// the limiter pauses when the server reports that the rate limit is exhausted.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *Client) {
+		if rps > 0 {
+			c.limiter = NewRateLimiter(rps, burst)
+		}
 	}
 }
 
+// WithRateLimiter shares an existing rate limiter with the client
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent requests
+func WithMaxInFlight(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.inFlight = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithMaxResponseSize limits the decompressed size of response bodies. Reading
+// past the limit fails with ErrResponseTooLarge. Zero keeps the default limit
+// and a negative value disables the guard.
+func WithMaxResponseSize(n int64) ClientOption {
+	return func(c *Client) {
+		if n != 0 {
+			c.maxResponseSize = n
+		}
+	}
+}
+
+// NewClient creates a new HTTP client
+func NewClient(baseURL string, options ...ClientOption) *Client {
+	return NewClientWithHTTPClient(baseURL, &http.Client{
+		Timeout: 30 * time.Second,
+	}, options...)
+}
+
 // NewClientWithHTTPClient creates a new client with a custom HTTP client
-func NewClientWithHTTPClient(baseURL string, httpClient *http.Client) *Client {
-	return &Client{
-		httpClient: httpClient,
-		baseURL:    baseURL,
+func NewClientWithHTTPClient(baseURL string, httpClient *http.Client, options ...ClientOption) *Client {
+	c := &Client{
+		httpClient:      httpClient,
+		baseURL:         baseURL,
+		logger:          DiscardLogger(),
+		maxResponseSize: DefaultMaxResponseSize,
 	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
 }
 
 // Get performs a GET request
@@ -76,8 +154,10 @@ func (c *Client) Delete(ctx context.Context, url string, headers map[string]stri
 	return c.do(ctx, http.MethodDelete, url, nil, headers)
 }
 
-// do performs the actual HTTP request
-func (c *Client) do(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*Response, error) {
+// Stream performs a request and returns the response with its body still
+// open, so callers can decode large payloads without buffering them. The
+// caller must close the body.
+func (c *Client) Stream(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*StreamResponse, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
@@ -95,16 +175,75 @@ func (c *Client) do(ctx context.Context, method, url string, body interface{}, h
 	// Set default headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
 
 	// Set custom headers
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
 
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("request not sent: %w", err)
+	}
+
+	c.logger.DebugContext(ctx, "sending request",
+		slog.String(LogKeyMethod, method),
+		slog.String(LogKeyURL, RedactURL(url)),
+	)
+
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		release()
+		c.logger.DebugContext(ctx, "request failed",
+			slog.String(LogKeyMethod, method),
+			slog.String(LogKeyURL, RedactURL(url)),
+			slog.Duration(LogKeyDuration, time.Since(start)),
+			slog.Any(LogKeyError, err),
+		)
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+
+	c.logger.DebugContext(ctx, "received response",
+		slog.String(LogKeyMethod, method),
+		slog.String(LogKeyURL, RedactURL(url)),
+		slog.Int(LogKeyStatus, resp.StatusCode),
+		slog.Duration(LogKeyDuration, time.Since(start)),
+	)
+
+	if c.limiter != nil {
+		if until, ok := rateLimitResetTime(resp, time.Now()); ok {
+			c.logger.WarnContext(ctx, "rate limited by server, pausing requests",
+				slog.String(LogKeyURL, RedactURL(url)),
+				slog.Int(LogKeyStatus, resp.StatusCode),
+				slog.Time("until", until),
+			)
+			c.limiter.PauseUntil(until)
+		}
+	}
+
+	respBody, err := decodeBody(resp, c.maxResponseSize)
+	if err != nil {
+		resp.Body.Close()
+		release()
+		return nil, err
+	}
+
+	return &StreamResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    resp.Header,
+		Body:       &releasingBody{ReadCloser: respBody, release: release},
+	}, nil
+}
+
+// do performs the actual HTTP request and buffers the response body
+func (c *Client) do(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*Response, error) {
+	resp, err := c.Stream(ctx, method, url, body, headers)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
@@ -115,11 +254,35 @@ func (c *Client) do(ctx context.Context, method, url string, body interface{}, h
 	return &Response{
 		StatusCode: resp.StatusCode,
 		Status:     resp.Status,
-		Headers:    resp.Header,
+		Headers:    resp.Headers,
 		Body:       respBody,
 	}, nil
 }
 
+// acquire waits for a concurrency slot and rate limit capacity. The returned
+// function releases the concurrency slot.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+
+	if c.inFlight != nil {
+		select {
+		case c.inFlight <- struct{}{}:
+			release = func() { <-c.inFlight }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
 // CreateHeaders creates a standard set of headers with optional API key
 func CreateHeaders(apiKey string) map[string]string {
 	headers := map[string]string{
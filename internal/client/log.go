@@ -0,0 +1,43 @@
+package client
+
+import (
+	"log/slog"
+	"net/url"
+)
+
+// Attribute keys shared by every log record emitted by the SDK
+const (
+	LogKeyService  = "service"
+	LogKeyMethod   = "method"
+	LogKeyURL      = "url"
+	LogKeyStatus   = "status"
+	LogKeyDuration = "duration"
+	LogKeyError    = "error"
+	LogKeyToggle   = "toggle"
+)
+
+// DiscardLogger returns a logger that drops every record
+func DiscardLogger() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// ServiceLogger returns a logger tagged with the service name, or a discarding
+// logger when none is configured
+func ServiceLogger(logger *slog.Logger, service string) *slog.Logger {
+	if logger == nil {
+		return DiscardLogger()
+	}
+	return logger.With(slog.String(LogKeyService, service))
+}
+
+// RedactURL strips credentials from a URL before it is logged. API keys are
+// only ever sent as headers, which are never logged, but user info in a
+// self-hosted base URL would otherwise leak.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiter that blocks callers until a request
+// may be sent. It also honors pauses requested by the server through
+// rate-limit response headers.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second with bursts
+// of up to burst requests. A burst below one is treated as one.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available, the server pause has elapsed, or
+// ctx is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := r.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available and otherwise returns how long to
+// wait before trying again
+func (r *RateLimiter) reserve(now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Before(r.pausedUntil) {
+		return r.pausedUntil.Sub(now)
+	}
+
+	if r.rate <= 0 {
+		return 0
+	}
+
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+}
+
+// PauseUntil blocks all callers until the given time
+func (r *RateLimiter) PauseUntil(until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+}
+
+// rateLimitResetTime derives when requests may resume from the rate-limit
+// headers of a response. It returns false when the response does not ask the
+// client to slow down.
+func rateLimitResetTime(resp *http.Response, now time.Time) (time.Time, bool) {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if until, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+			return until, true
+		}
+	}
+
+	remaining := firstHeader(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	if remaining != "0" {
+		return time.Time{}, false
+	}
+
+	reset, err := strconv.ParseInt(firstHeader(resp.Header, "X-RateLimit-Reset", "RateLimit-Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return time.Time{}, false
+	}
+
+	// Values that look like a Unix timestamp are absolute, anything smaller is
+	// a number of seconds from now
+	if reset > 1_000_000_000 {
+		return time.Unix(reset, 0), true
+	}
+	return now.Add(time.Duration(reset) * time.Second), true
+}
+
+// parseRetryAfter parses a Retry-After header in either delay-seconds or
+// HTTP-date form
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
+
+// firstHeader returns the value of the first header that is set
+func firstHeader(header http.Header, keys ...string) string {
+	for _, key := range keys {
+		if value := header.Get(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
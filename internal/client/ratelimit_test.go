@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("allows_a_burst_then_blocks_until_tokens_refill", func(t *testing.T) {
+		limiter := NewRateLimiter(20, 2)
+		start := time.Now()
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, limiter.Wait(context.Background()))
+		}
+
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("returns_the_context_error_when_cancelled_while_waiting", func(t *testing.T) {
+		limiter := NewRateLimiter(0.001, 1)
+		assert.NoError(t, limiter.Wait(context.Background()))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := limiter.Wait(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("blocks_until_a_server_pause_has_elapsed", func(t *testing.T) {
+		limiter := NewRateLimiter(1000, 10)
+		limiter.PauseUntil(time.Now().Add(30 * time.Millisecond))
+		start := time.Now()
+
+		assert.NoError(t, limiter.Wait(context.Background()))
+
+		assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+	})
+}
+
+func TestRateLimitResetTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("uses_retry_after_seconds_on_429", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"5"}}}
+
+		until, ok := rateLimitResetTime(resp, now)
+
+		assert.True(t, ok)
+		assert.Equal(t, now.Add(5*time.Second), until)
+	})
+
+	t.Run("uses_the_reset_header_when_remaining_is_zero", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"3"},
+		}}
+
+		until, ok := rateLimitResetTime(resp, now)
+
+		assert.True(t, ok)
+		assert.Equal(t, now.Add(3*time.Second), until)
+	})
+
+	t.Run("treats_large_reset_values_as_unix_timestamps", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{
+			"Ratelimit-Remaining": {"0"},
+			"Ratelimit-Reset":     {"1704067210"},
+		}}
+
+		until, ok := rateLimitResetTime(resp, now)
+
+		assert.True(t, ok)
+		assert.Equal(t, time.Unix(1704067210, 0), until)
+	})
+
+	t.Run("ignores_responses_with_remaining_capacity", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"X-Ratelimit-Remaining": {"10"}}}
+
+		_, ok := rateLimitResetTime(resp, now)
+
+		assert.False(t, ok)
+	})
+}
+
+func TestMaxInFlight(t *testing.T) {
+	t.Run("never_exceeds_the_configured_concurrency", func(t *testing.T) {
+		var current, peak atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := current.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			current.Add(-1)
+		}))
+		t.Cleanup(server.Close)
+		c := NewClient(server.URL, WithMaxInFlight(2))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.Get(context.Background(), server.URL, nil)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.LessOrEqual(t, peak.Load(), int32(2))
+	})
+
+	t.Run("returns_an_error_when_the_context_is_cancelled_while_waiting", func(t *testing.T) {
+		c := NewClient("", WithMaxInFlight(1))
+		c.inFlight <- struct{}{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := c.Get(ctx, "http://example.invalid", nil)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// acceptEncoding lists the content codings the client can decode. Brotli is
+// not advertised because the standard library has no decoder for it.
+const acceptEncoding = "gzip, deflate"
+
+// DefaultMaxResponseSize is the default limit on decompressed response bodies
+const DefaultMaxResponseSize int64 = 32 << 20
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's
+// maximum response size
+var ErrResponseTooLarge = errors.New("response body exceeds maximum size")
+
+// Streamer is implemented by HTTP clients that can hand back an unread
+// response body
+type Streamer interface {
+	Stream(ctx context.Context, method, url string, body interface{}, headers map[string]string) (*StreamResponse, error)
+}
+
+// Stream performs a request through c and returns the response with an open
+// body. Clients that do not implement Streamer fall back to a buffered request
+// whose body is wrapped in a reader, so callers can use the same decoding code
+// either way.
+func Stream(ctx context.Context, c HTTPClient, method, url string, body interface{}, headers map[string]string) (*StreamResponse, error) {
+	if s, ok := c.(Streamer); ok {
+		return s.Stream(ctx, method, url, body, headers)
+	}
+
+	var resp *Response
+	var err error
+	switch method {
+	case http.MethodGet:
+		resp, err = c.Get(ctx, url, headers)
+	case http.MethodPost:
+		resp, err = c.Post(ctx, url, body, headers)
+	case http.MethodPut:
+		resp, err = c.Put(ctx, url, body, headers)
+	case http.MethodPatch:
+		resp, err = c.Patch(ctx, url, body, headers)
+	case http.MethodDelete:
+		resp, err = c.Delete(ctx, url, headers)
+	default:
+		return nil, fmt.Errorf("unsupported method %s", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    resp.Headers,
+		Body:       io.NopCloser(bytes.NewReader(resp.Body)),
+	}, nil
+}
+
+// decodeBody wraps the response body with a decompressor matching its
+// Content-Encoding and a size guard. Responses without a body are passed
+// through, since there is nothing to decompress even when they name an
+// encoding.
+func decodeBody(resp *http.Response, maxSize int64) (io.ReadCloser, error) {
+	var body io.Reader = resp.Body
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if !hasBody(resp) {
+		encoding = ""
+	}
+	switch encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip response: %w", err)
+		}
+		body = gz
+	case "deflate":
+		body = newDeflateReader(resp.Body)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	if encoding != "" {
+		// The body handed back is decoded, so these no longer describe it
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+	}
+
+	if maxSize > 0 {
+		body = &limitedReader{r: body, remaining: maxSize}
+	}
+
+	return &decodedBody{Reader: body, closer: resp.Body}, nil
+}
+
+// hasBody reports whether a response can carry a body to decode
+func hasBody(resp *http.Response) bool {
+	if resp.ContentLength == 0 || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return false
+	}
+	return resp.Request == nil || resp.Request.Method != http.MethodHead
+}
+
+// newDeflateReader reads a "deflate" coded body. The coding is specified as
+// zlib-wrapped, but some servers send raw deflate streams, so both are accepted.
+func newDeflateReader(r io.Reader) io.Reader {
+	buffered := &peekReader{r: r}
+	header := make([]byte, 2)
+	n, _ := io.ReadFull(buffered, header)
+	buffered.prefix = header[:n]
+
+	if n == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zr, err := zlib.NewReader(buffered)
+		if err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(buffered)
+}
+
+// peekReader replays a prefix that was read to sniff the stream format
+type peekReader struct {
+	r      io.Reader
+	prefix []byte
+}
+
+func (p *peekReader) Read(b []byte) (int, error) {
+	if len(p.prefix) > 0 {
+		n := copy(b, p.prefix)
+		p.prefix = p.prefix[n:]
+		return n, nil
+	}
+	return p.r.Read(b)
+}
+
+// limitedReader fails with ErrResponseTooLarge once more than remaining bytes
+// have been read
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(b)) > l.remaining+1 {
+		b = b[:l.remaining+1]
+	}
+	n, err := l.r.Read(b)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// decodedBody closes the underlying network body when the decoded reader is
+// closed
+type decodedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	return d.closer.Close()
+}
+
+// releasingBody frees the client's concurrency slot when the body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (r *releasingBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
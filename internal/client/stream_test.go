@@ -0,0 +1,176 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compressed(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "deflate":
+		var err error
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCompression(t *testing.T) {
+	theBody := []byte(`{"hello":"world"}`)
+
+	for _, tc := range []struct {
+		name     string
+		header   string
+		encoding string
+	}{
+		{"decodes_gzip_responses", "gzip", "gzip"},
+		{"decodes_zlib_wrapped_deflate_responses", "deflate", "zlib"},
+		{"decodes_raw_deflate_responses", "deflate", "deflate"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var acceptEncodingHeader string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				acceptEncodingHeader = r.Header.Get("Accept-Encoding")
+				w.Header().Set("Content-Encoding", tc.header)
+				w.Write(compressed(t, tc.encoding, theBody))
+			}))
+			t.Cleanup(server.Close)
+
+			resp, err := NewClient(server.URL).Get(context.Background(), server.URL, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, "gzip, deflate", acceptEncodingHeader)
+			assert.Equal(t, theBody, resp.Body)
+			assert.Empty(t, resp.Headers.Get("Content-Encoding"))
+		})
+	}
+}
+
+func TestEmptyEncodedResponses(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+	}{
+		{"accepts_a_204_naming_an_encoding", http.StatusNoContent},
+		{"accepts_an_empty_200_naming_an_encoding", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Header().Set("Content-Length", "0")
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(server.Close)
+
+			resp, err := NewClient(server.URL).Delete(context.Background(), server.URL, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Empty(t, resp.Body)
+		})
+	}
+
+	t.Run("accepts_a_head_response_naming_an_encoding", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Length", "120")
+		}))
+		t.Cleanup(server.Close)
+		req, err := http.NewRequest(http.MethodHead, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+
+		body, err := decodeBody(resp, 0)
+
+		require.NoError(t, err)
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Empty(t, data)
+	})
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed(t, "gzip", bytes.Repeat([]byte("a"), 1024)))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("fails_when_the_decompressed_body_exceeds_the_limit", func(t *testing.T) {
+		_, err := NewClient(server.URL, WithMaxResponseSize(100)).Get(context.Background(), server.URL, nil)
+
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+	})
+
+	t.Run("reads_bodies_that_fit_exactly", func(t *testing.T) {
+		resp, err := NewClient(server.URL, WithMaxResponseSize(1024)).Get(context.Background(), server.URL, nil)
+
+		require.NoError(t, err)
+		assert.Len(t, resp.Body, 1024)
+	})
+
+	t.Run("does_not_limit_when_disabled", func(t *testing.T) {
+		resp, err := NewClient(server.URL, WithMaxResponseSize(-1)).Get(context.Background(), server.URL, nil)
+
+		require.NoError(t, err)
+		assert.Len(t, resp.Body, 1024)
+	})
+}
+
+type bufferedOnlyClient struct {
+	HTTPClient
+	response *Response
+}
+
+func (b *bufferedOnlyClient) Get(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return b.response, nil
+}
+
+func TestStream(t *testing.T) {
+	t.Run("wraps_buffered_responses_from_clients_that_cannot_stream", func(t *testing.T) {
+		c := &bufferedOnlyClient{response: &Response{StatusCode: http.StatusOK, Body: []byte("theBody")}}
+
+		resp, err := Stream(context.Background(), c, http.MethodGet, "http://example.invalid", nil, nil)
+
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "theBody", string(body))
+	})
+
+	t.Run("releases_the_concurrency_slot_when_the_body_is_closed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("{}"))
+		}))
+		t.Cleanup(server.Close)
+		c := NewClient(server.URL, WithMaxInFlight(1))
+
+		resp, err := c.Stream(context.Background(), http.MethodGet, server.URL, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, c.inFlight, 1)
+		resp.Body.Close()
+
+		assert.Len(t, c.inFlight, 0)
+	})
+}
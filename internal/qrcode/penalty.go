@@ -0,0 +1,104 @@
+package qrcode
+
+// Penalty weights from the mask evaluation rules of the specification
+const (
+	penaltyRun     = 3  // Five or more same-colored modules in a row or column
+	penaltyBlock   = 3  // Each 2x2 block of same-colored modules
+	penaltyFinder  = 40 // Each finder-like 1:1:3:1:1 pattern
+	penaltyBalance = 10 // Each 5% step away from half dark modules
+)
+
+// penalty scores the current modules; lower is easier to scan
+func (c *Code) penalty() int {
+	result := 0
+
+	for y := range c.Size {
+		result += c.linePenalty(func(i int) bool { return c.modules[y*c.Size+i] })
+	}
+	for x := range c.Size {
+		result += c.linePenalty(func(i int) bool { return c.modules[i*c.Size+x] })
+	}
+
+	for y := range c.Size - 1 {
+		for x := range c.Size - 1 {
+			dark := c.Dark(x, y)
+			if dark == c.Dark(x+1, y) && dark == c.Dark(x, y+1) && dark == c.Dark(x+1, y+1) {
+				result += penaltyBlock
+			}
+		}
+	}
+
+	dark := 0
+	for _, m := range c.modules {
+		if m {
+			dark++
+		}
+	}
+	total := c.Size * c.Size
+	// Smallest k such that the dark ratio is within (45-5k)% to (55+5k)%
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyBalance
+
+	return result
+}
+
+// linePenalty scores runs and finder-like patterns along one row or column
+func (c *Code) linePenalty(module func(int) bool) int {
+	result := 0
+	runColor := false
+	runLength := 0
+	var history runHistory
+	for i := range c.Size {
+		if module(i) == runColor {
+			runLength++
+			if runLength == 5 {
+				result += penaltyRun
+			} else if runLength > 5 {
+				result++
+			}
+			continue
+		}
+		history.add(runLength, c.Size)
+		if !runColor {
+			result += history.finderPatterns() * penaltyFinder
+		}
+		runColor = module(i)
+		runLength = 1
+	}
+
+	// Terminate the line against the light quiet zone
+	if runColor {
+		history.add(runLength, c.Size)
+		runLength = 0
+	}
+	history.add(runLength+c.Size, c.Size)
+	result += history.finderPatterns() * penaltyFinder
+
+	return result
+}
+
+// runHistory holds the lengths of the most recent runs, newest first
+type runHistory [7]int
+
+func (h *runHistory) add(length, size int) {
+	if h[0] == 0 {
+		length += size // The first run borders the light quiet zone
+	}
+	copy(h[1:], h[:6])
+	h[0] = length
+}
+
+// finderPatterns counts the 1:1:3:1:1 patterns with four light modules on
+// either side that end at the newest run
+func (h *runHistory) finderPatterns() int {
+	n := h[1]
+	core := n > 0 && h[2] == n && h[3] == n*3 && h[4] == n && h[5] == n
+	count := 0
+	if core && h[0] >= n*4 && h[6] >= n {
+		count++
+	}
+	if core && h[6] >= n*4 && h[0] >= n {
+		count++
+	}
+	return count
+}
@@ -0,0 +1,418 @@
+// Package qrcode encodes data into QR code symbols (ISO/IEC 18004) using byte
+// mode, so short links can be rendered without calling the QR code service.
+package qrcode
+
+import (
+	"errors"
+)
+
+// ECLevel is the error correction level of a symbol
+type ECLevel int
+
+const (
+	Low      ECLevel = iota // Recovers about 7% of the symbol
+	Medium                  // Recovers about 15% of the symbol
+	Quartile                // Recovers about 25% of the symbol
+	High                    // Recovers about 30% of the symbol
+)
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+// ErrDataTooLong is returned when the data does not fit in the largest symbol
+var ErrDataTooLong = errors.New("qrcode: data too long")
+
+// formatBits are the two bits identifying each error correction level in the
+// format information
+var formatBits = [...]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+// eccCodewordsPerBlock is indexed by level and version; version 0 is unused
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// numErrorCorrectionBlocks is indexed by level and version; version 0 is unused
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code is an encoded QR code symbol
+type Code struct {
+	Version int     // Symbol version from 1 to 40
+	Size    int     // Width and height in modules, excluding the quiet zone
+	Level   ECLevel // Error correction level
+	Mask    int     // Mask pattern from 0 to 7
+
+	modules    []bool // Dark modules in row-major order
+	isFunction []bool // Modules reserved for function patterns while encoding
+}
+
+// Dark reports whether the module at column x and row y is dark. Coordinates
+// outside the symbol are light, which makes the quiet zone implicit.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+// Encode encodes data in byte mode at the smallest version that fits, with the
+// mask pattern that scores the lowest penalty
+func Encode(data []byte, level ECLevel) (*Code, error) {
+	return encode(data, level, -1)
+}
+
+// encode encodes data with the given mask, or the best one when mask is negative
+func encode(data []byte, level ECLevel, mask int) (*Code, error) {
+	if level < Low || level > High {
+		return nil, errors.New("qrcode: invalid error correction level")
+	}
+
+	version := 0
+	for v := minVersion; v <= maxVersion; v++ {
+		if len(data) < 1<<charCountBits(v) && dataBitsUsed(len(data), v) <= numDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrDataTooLong
+	}
+
+	// Byte mode segment, terminator and padding
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := numDataCodewords(version, level) * 8
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	size := version*4 + 17
+	c := &Code{
+		Version:    version,
+		Size:       size,
+		Level:      level,
+		modules:    make([]bool, size*size),
+		isFunction: make([]bool, size*size),
+	}
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.addECCAndInterleave(bits.bytes()))
+
+	if mask < 0 {
+		best := 0
+		minPenalty := -1
+		for m := range 8 {
+			c.applyMask(m)
+			c.drawFormatBits(m)
+			if penalty := c.penalty(); minPenalty < 0 || penalty < minPenalty {
+				best, minPenalty = m, penalty
+			}
+			c.applyMask(m) // Masking is an XOR, so applying it again undoes it
+		}
+		mask = best
+	}
+	c.Mask = mask
+	c.applyMask(mask)
+	c.drawFormatBits(mask)
+	c.isFunction = nil
+
+	return c, nil
+}
+
+// charCountBits is the width of the byte mode character count for a version
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// dataBitsUsed is the number of bits a byte mode segment of n bytes needs
+func dataBitsUsed(n, version int) int {
+	return 4 + charCountBits(version) + n*8
+}
+
+// numRawDataModules is the number of modules available for data and error
+// correction codewords, including remainder bits
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// numDataCodewords is the number of 8-bit data codewords of a symbol
+func numDataCodewords(version int, level ECLevel) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// alignmentPatternPositions returns the ascending center coordinates of the
+// alignment patterns, used for both rows and columns
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+	c.isFunction[y*c.Size+x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := range c.Size {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	positions := alignmentPatternPositions(c.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners occupied by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// Reserve the format areas now; the real bits are drawn after masking
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinderPattern draws a finder pattern and its separator centered on x, y
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.Size || yy >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information and the dark module
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatInformation(c.Level, mask)
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// formatInformation returns the 15 format bits for a level and mask: five data
+// bits, ten BCH error correction bits, XORed with the fixed format mask
+func formatInformation(level ECLevel, mask int) int {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawVersion draws both copies of the version information for version 7 and up
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | rem
+
+	for i := range 18 {
+		dark := bit(bits, i)
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// addECCAndInterleave splits the data codewords into blocks, appends the
+// Reed-Solomon codewords of each block and interleaves the result
+func (c *Code) addECCAndInterleave(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	blockECCLen := eccCodewordsPerBlock[c.Level][c.Version]
+	rawCodewords := numRawDataModules(c.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range numBlocks {
+		n := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+n]...)
+		k += n
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			// Placeholder keeping the columns aligned; skipped when interleaving
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range len(blocks[0]) {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords places the codewords in the zigzag pattern, two columns at a
+// time from the bottom right, skipping function modules
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // The vertical timing pattern occupies column 6
+		}
+		upward := (right+1)&2 == 0
+		for vert := range c.Size {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if !c.isFunction[y*c.Size+x] && i < len(data)*8 {
+					c.set(x, y, bit(int(data[i>>3]), 7-i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask XORs the mask pattern onto every non-function module
+func (c *Code) applyMask(mask int) {
+	for y := range c.Size {
+		for x := range c.Size {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			i := y*c.Size + x
+			if invert && !c.isFunction[i] {
+				c.modules[i] = !c.modules[i]
+			}
+		}
+	}
+}
+
+func bit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// bitBuffer accumulates bits most significant first
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, bit(value, i))
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, set := range b {
+		if set {
+			result[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return result
+}
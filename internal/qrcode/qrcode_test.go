@@ -0,0 +1,129 @@
+package qrcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	t.Run("encodes_a_short_url", func(t *testing.T) {
+		// Cross-checked against an independent encoder
+		expected := []string{
+			"#######.##.#....#.#######",
+			"#.....#.#.##..#.#.#.....#",
+			"#.###.#..#.#####..#.###.#",
+			"#.###.#.#..####...#.###.#",
+			"#.###.#...##..##..#.###.#",
+			"#.....#...#.#.....#.....#",
+			"#######.#.#.#.#.#.#######",
+			"........##.#..##.........",
+			"#.##.###..#.#.###.#..#.##",
+			".#.##..##...#...##.....#.",
+			".##..##.#######.....#....",
+			"##.##..#..##.###...####..",
+			"#####.###..#.....##.#.###",
+			".......#...#.########...#",
+			".##.###..##.#.#..##.#.##.",
+			"#..#.#.##.#.##.#...##...#",
+			"..###.##.#.#...##########",
+			"........#...##..#...#.#.#",
+			"#######.#####...#.#.#.###",
+			"#.....#.#...#..##...#..#.",
+			"#.###.#...####..######.#.",
+			"#.###.#.#...####..#.#####",
+			"#.###.#.######.####.#.##.",
+			"#.....#..##......##.#.#..",
+			"#######.#...#.#....######",
+		}
+
+		code, err := Encode([]byte("https://h4n.link/abc"), Medium)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, code.Version)
+		assert.Equal(t, 3, code.Mask)
+		assert.Equal(t, expected, render(code))
+	})
+
+	t.Run("picks_the_smallest_version_that_fits", func(t *testing.T) {
+		tests := []struct {
+			n       int
+			level   ECLevel
+			version int
+		}{
+			{17, Low, 1},
+			{18, Low, 2},
+			{7, High, 1},
+			{8, High, 2},
+			{2953, Low, 40},
+			{1273, High, 40},
+		}
+		for _, tt := range tests {
+			code, err := Encode(bytes.Repeat([]byte("a"), tt.n), tt.level)
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, code.Version, "%d bytes at level %d", tt.n, tt.level)
+			assert.Equal(t, tt.version*4+17, code.Size)
+		}
+	})
+
+	t.Run("rejects_data_that_does_not_fit", func(t *testing.T) {
+		_, err := Encode(bytes.Repeat([]byte("a"), 1274), High)
+
+		assert.ErrorIs(t, err, ErrDataTooLong)
+	})
+
+	t.Run("treats_coordinates_outside_the_symbol_as_light", func(t *testing.T) {
+		code, err := Encode([]byte("x"), Low)
+		require.NoError(t, err)
+
+		assert.True(t, code.Dark(0, 0))
+		assert.False(t, code.Dark(-1, 0))
+		assert.False(t, code.Dark(code.Size, 0))
+	})
+}
+
+func TestCapacities(t *testing.T) {
+	// Data codewords from the capacity table of the specification
+	assert.Equal(t, 19, numDataCodewords(1, Low))
+	assert.Equal(t, 9, numDataCodewords(1, High))
+	assert.Equal(t, 216, numDataCodewords(10, Medium))
+	assert.Equal(t, 2956, numDataCodewords(40, Low))
+	assert.Equal(t, 1276, numDataCodewords(40, High))
+
+	assert.Equal(t, []int{6, 22, 38}, alignmentPatternPositions(7))
+	assert.Equal(t, []int{6, 34, 60, 86, 112, 138}, alignmentPatternPositions(32))
+}
+
+func TestReedSolomon(t *testing.T) {
+	// The 1-M "HELLO WORLD" example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+
+	ecc := reedSolomonRemainder(data, reedSolomonDivisor(10))
+
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ecc)
+}
+
+func TestFormatInformation(t *testing.T) {
+	assert.Equal(t, 0b110011000101111, formatInformation(Low, 4))
+	assert.Equal(t, 0b101010000010010, formatInformation(Medium, 0))
+}
+
+// render draws a symbol as rows of # for dark and . for light modules
+func render(code *Code) []string {
+	rows := make([]string, code.Size)
+	for y := range code.Size {
+		var row strings.Builder
+		for x := range code.Size {
+			if code.Dark(x, y) {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		rows[y] = row.String()
+	}
+	return rows
+}
@@ -0,0 +1,435 @@
+// Package hyphentest provides helpers for testing code that uses the Hyphen
+// SDK without network access or live credentials.
+package hyphentest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// Mode controls whether a Recorder captures live traffic or replays a cassette
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the network
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real service and captures each exchange
+	ModeRecord
+)
+
+// RecordEnvVar is the environment variable that switches New into record mode
+const RecordEnvVar = "HYPHEN_RECORD"
+
+// redacted replaces secret values in recorded cassettes
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are scrubbed from every recorded request and response
+var sensitiveHeaders = []string{"x-api-key", "Authorization", "Cookie", "Set-Cookie"}
+
+// Body is a recorded HTTP body. It is stored under a key naming its encoding
+// so every body replays byte for byte: JSON payloads inline under "json" to
+// keep cassettes readable, other UTF-8 payloads under "text" and binary ones
+// under "base64". An empty body is stored as null.
+type Body []byte
+
+// encodedBody is the cassette form of a Body
+type encodedBody struct {
+	JSON   json.RawMessage `json:"json,omitempty"`
+	Text   *string         `json:"text,omitempty"`
+	Base64 []byte          `json:"base64,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+	var encoded encodedBody
+	switch {
+	case len(b) == 0:
+		return []byte("null"), nil
+	case json.Valid(b):
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err != nil {
+			return nil, err
+		}
+		encoded.JSON = buf.Bytes()
+	case utf8.Valid(b):
+		text := string(b)
+		encoded.Text = &text
+	default:
+		encoded.Base64 = b
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*b = nil
+		return nil
+	}
+
+	var encoded encodedBody
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("body must be null or an object with a json, text or base64 key: %w", err)
+	}
+	switch {
+	case encoded.JSON != nil:
+		*b = Body(encoded.JSON)
+	case encoded.Text != nil:
+		*b = Body(*encoded.Text)
+	default:
+		*b = Body(encoded.Base64)
+	}
+	return nil
+}
+
+// RecordedRequest is the request half of an Interaction
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body"`
+}
+
+// RecordedResponse is the response half of an Interaction
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       Body        `json:"body"`
+}
+
+// Interaction is a single recorded HTTP exchange
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is the golden file format holding recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Matcher reports whether a recorded request matches an outgoing request.
+// body is the outgoing request body, already read.
+type Matcher func(req *http.Request, body []byte, recorded RecordedRequest) bool
+
+// DefaultMatcher matches on method, URL path and body. Hosts and query strings
+// are ignored so cassettes recorded against one environment replay against
+// another. JSON bodies are compared semantically.
+func DefaultMatcher(req *http.Request, body []byte, recorded RecordedRequest) bool {
+	if req.Method != recorded.Method {
+		return false
+	}
+	if req.URL.Path != recordedPath(recorded.URL) {
+		return false
+	}
+	return bodiesEqual(body, recorded.Body)
+}
+
+// MatchQuery extends DefaultMatcher to also require identical query strings
+func MatchQuery(req *http.Request, body []byte, recorded RecordedRequest) bool {
+	if !DefaultMatcher(req, body, recorded) {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return req.URL.Query().Encode() == recordedURL.Query().Encode()
+}
+
+// RecorderOption configures a Recorder
+type RecorderOption func(*Recorder)
+
+// WithTransport sets the transport used to reach the real service in record
+// mode. Defaults to http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithMatcher replaces DefaultMatcher
+func WithMatcher(matcher Matcher) RecorderOption {
+	return func(r *Recorder) {
+		r.matcher = matcher
+	}
+}
+
+// WithScrubber adds a function that is applied to every interaction before it
+// is stored, in addition to the built-in API key scrubbing
+func WithScrubber(scrub func(*Interaction)) RecorderOption {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrub)
+	}
+}
+
+// Recorder is an http.RoundTripper that records Hyphen HTTP exchanges to a
+// cassette file or replays them from one
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+	matcher   Matcher
+	scrubbers []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder creates a Recorder for the cassette at path. In replay mode the
+// cassette must already exist.
+func NewRecorder(path string, mode Mode, options ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: http.DefaultTransport,
+		matcher:   DefaultMatcher,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cassette: %w", err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// New creates a Recorder for testdata/<name>.json. It records when the
+// HYPHEN_RECORD environment variable is "true" or "1" and replays otherwise.
+// Recorded cassettes are saved when the test finishes.
+func New(t testing.TB, name string, options ...RecorderOption) *Recorder {
+	t.Helper()
+
+	mode := ModeReplay
+	if v := strings.ToLower(os.Getenv(RecordEnvVar)); v == "true" || v == "1" {
+		mode = ModeRecord
+	}
+
+	r, err := NewRecorder(filepath.Join("testdata", name+".json"), mode, options...)
+	if err != nil {
+		t.Fatalf("hyphentest: %v", err)
+	}
+
+	if mode == ModeRecord {
+		t.Cleanup(func() {
+			if err := r.Save(); err != nil {
+				t.Errorf("hyphentest: %v", err)
+			}
+		})
+	}
+
+	return r
+}
+
+// Mode returns whether the recorder is recording or replaying
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an *http.Client that sends requests through the recorder,
+// suitable for the WithHTTPClient option of every service
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns a copy of the interactions recorded or loaded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+// Save writes the recorded interactions to the cassette file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// record forwards the request to the real service and stores the exchange
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	// Ask for an identity response so the cassette stores plain bodies
+	outgoing.Header.Del("Accept-Encoding")
+
+	resp, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header.Clone(),
+			Body:    body,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       respBody,
+		},
+	}
+	r.scrub(&interaction)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return newResponse(req, resp.StatusCode, resp.Header.Clone(), respBody), nil
+}
+
+// replay serves the first unused interaction that matches the request
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !r.matcher(req, body, interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+		return newResponse(req, interaction.Response.StatusCode, interaction.Response.Headers.Clone(), interaction.Response.Body), nil
+	}
+
+	return nil, fmt.Errorf("hyphentest: no recorded interaction matches %s %s", req.Method, req.URL.Path)
+}
+
+// scrub removes credentials from an interaction before it is stored
+func (r *Recorder) scrub(interaction *Interaction) {
+	var secrets []string
+	for _, header := range sensitiveHeaders {
+		for _, value := range interaction.Request.Headers.Values(header) {
+			if value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+		if interaction.Request.Headers.Get(header) != "" {
+			interaction.Request.Headers.Set(header, redacted)
+		}
+		if interaction.Response.Headers.Get(header) != "" {
+			interaction.Response.Headers.Set(header, redacted)
+		}
+	}
+
+	// Keys can also be echoed back in URLs or bodies
+	for _, secret := range secrets {
+		interaction.Request.URL = strings.ReplaceAll(interaction.Request.URL, secret, redacted)
+		interaction.Request.Body = Body(bytes.ReplaceAll(interaction.Request.Body, []byte(secret), []byte(redacted)))
+		interaction.Response.Body = Body(bytes.ReplaceAll(interaction.Response.Body, []byte(secret), []byte(redacted)))
+	}
+
+	for _, scrub := range r.scrubbers {
+		scrub(interaction)
+	}
+}
+
+// readRequestBody reads and restores the request body
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// newResponse builds an *http.Response served to the SDK
+func newResponse(req *http.Request, statusCode int, headers http.Header, body []byte) *http.Response {
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Del("Content-Encoding")
+	headers.Del("Content-Length")
+
+	return &http.Response{
+		StatusCode:    statusCode,
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// recordedPath returns the path of a recorded URL
+func recordedPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// bodiesEqual compares request bodies, ignoring formatting and key order when
+// both are JSON
+func bodiesEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
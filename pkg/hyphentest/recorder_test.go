@@ -0,0 +1,151 @@
+package hyphentest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/pkg/link"
+	"github.com/Hyphen/go-sdk/pkg/netinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLinkServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(link.ShortCodeResponse{ID: "theId", Code: "theCode", LongURL: "https://hyphen.ai"})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRecorder(t *testing.T) {
+	theAPIKey := "theSecretApiKey"
+
+	t.Run("records_and_replays_link_requests_with_the_api_key_scrubbed", func(t *testing.T) {
+		server := newLinkServer(t)
+		cassette := filepath.Join(t.TempDir(), "link.json")
+
+		recorder, err := NewRecorder(cassette, ModeRecord)
+		require.NoError(t, err)
+		recording, err := link.New(
+			link.WithAPIKey(theAPIKey),
+			link.WithOrganizationID("theOrgId"),
+			link.WithURIs([]string{server.URL + "/api/organizations/{organizationId}/link/codes/"}),
+			link.WithHTTPClient(recorder.Client()),
+		)
+		require.NoError(t, err)
+		recorded, err := recording.CreateShortCode(context.Background(), "https://hyphen.ai", "h4n.link", nil)
+		require.NoError(t, err)
+		require.NoError(t, recorder.Save())
+		server.Close()
+
+		data, err := os.ReadFile(cassette)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), theAPIKey)
+		assert.Contains(t, string(data), redacted)
+
+		replayer, err := NewRecorder(cassette, ModeReplay)
+		require.NoError(t, err)
+		replaying, err := link.New(
+			link.WithAPIKey("aDifferentKey"),
+			link.WithOrganizationID("theOrgId"),
+			link.WithURIs([]string{"https://api.test.com/api/organizations/{organizationId}/link/codes/"}),
+			link.WithHTTPClient(replayer.Client()),
+		)
+		require.NoError(t, err)
+		replayed, err := replaying.CreateShortCode(context.Background(), "https://hyphen.ai", "h4n.link", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, recorded, replayed)
+	})
+
+	t.Run("fails_when_no_interaction_matches", func(t *testing.T) {
+		cassette := filepath.Join(t.TempDir(), "empty.json")
+		require.NoError(t, os.WriteFile(cassette, []byte(`{"interactions":[]}`), 0o644))
+		replayer, err := NewRecorder(cassette, ModeReplay)
+		require.NoError(t, err)
+		netInfo, err := netinfo.New(
+			netinfo.WithAPIKey(theAPIKey),
+			netinfo.WithHTTPClient(replayer.Client()),
+		)
+		require.NoError(t, err)
+
+		_, err = netInfo.GetIPInfo(context.Background(), "8.8.8.8")
+
+		assert.ErrorContains(t, err, "no recorded interaction matches GET /ip/8.8.8.8")
+	})
+
+	t.Run("replays_each_interaction_once_in_order", func(t *testing.T) {
+		cassette := filepath.Join(t.TempDir(), "pages.json")
+		require.NoError(t, os.WriteFile(cassette, []byte(`{"interactions":[
+			{"request":{"method":"GET","url":"https://net.info/ip/1.1.1.1","body":null},"response":{"statusCode":200,"body":{"json":{"ip":"1.1.1.1","type":"first"}}}},
+			{"request":{"method":"GET","url":"https://net.info/ip/1.1.1.1","body":null},"response":{"statusCode":200,"body":{"json":{"ip":"1.1.1.1","type":"second"}}}}
+		]}`), 0o644))
+		replayer, err := NewRecorder(cassette, ModeReplay)
+		require.NoError(t, err)
+		netInfo, err := netinfo.New(netinfo.WithAPIKey(theAPIKey), netinfo.WithHTTPClient(replayer.Client()))
+		require.NoError(t, err)
+
+		first, err := netInfo.GetIPInfo(context.Background(), "1.1.1.1")
+		require.NoError(t, err)
+		second, err := netInfo.GetIPInfo(context.Background(), "1.1.1.1")
+		require.NoError(t, err)
+
+		assert.Equal(t, "first", first.Type)
+		assert.Equal(t, "second", second.Type)
+	})
+
+	t.Run("replays_a_json_string_body_as_recorded", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		}))
+		t.Cleanup(server.Close)
+		cassette := filepath.Join(t.TempDir(), "string.json")
+		post := func(client *http.Client, url string) string {
+			resp, err := client.Post(url+"/echo", "application/json", strings.NewReader(`"theBody"`))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return string(body)
+		}
+
+		recorder, err := NewRecorder(cassette, ModeRecord)
+		require.NoError(t, err)
+		assert.Equal(t, `"theBody"`, post(recorder.Client(), server.URL))
+		require.NoError(t, recorder.Save())
+		server.Close()
+
+		replayer, err := NewRecorder(cassette, ModeReplay)
+		require.NoError(t, err)
+		assert.Equal(t, `"theBody"`, post(replayer.Client(), "https://api.test.com"))
+	})
+
+	t.Run("round_trips_bodies_of_every_kind", func(t *testing.T) {
+		for _, body := range []Body{nil, Body(`"theBody"`), Body(`null`), Body(`{"a":1}`), Body("plain text"), Body{0xff, 0x00}} {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			var decoded Body
+			require.NoError(t, json.Unmarshal(data, &decoded))
+
+			assert.Equal(t, []byte(body), []byte(decoded), string(data))
+		}
+	})
+
+	t.Run("matches_json_bodies_regardless_of_key_order", func(t *testing.T) {
+		assert.True(t, bodiesEqual([]byte(`{"a":1,"b":2}`), []byte(`{ "b": 2, "a": 1 }`)))
+		assert.False(t, bodiesEqual([]byte(`{"a":1}`), []byte(`{"a":2}`)))
+	})
+}
@@ -0,0 +1,816 @@
+package hyphentest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hyphen "github.com/Hyphen/go-sdk"
+	"github.com/Hyphen/go-sdk/pkg/link"
+	"github.com/Hyphen/go-sdk/pkg/netinfo"
+	"github.com/Hyphen/go-sdk/pkg/toggle"
+)
+
+// Default credentials accepted by a fake Server
+const (
+	DefaultAPIKey         = "test_api_key"
+	DefaultPublicAPIKey   = "public_dGVzdC1vcmc6c2VjcmV0" // test-org:secret
+	DefaultOrganizationID = "test-org"
+	DefaultApplicationID  = "test-app"
+)
+
+// Fault describes an error condition injected into fake server responses
+type Fault struct {
+	Method        string        // HTTP method to match, empty matches all
+	Path          string        // Path prefix to match, empty matches all
+	Latency       time.Duration // Delay before responding
+	StatusCode    int           // Status code to respond with instead of the real response
+	MalformedJSON bool          // Respond with 200 and a truncated JSON body
+	Times         int           // Number of requests to affect, zero affects all
+}
+
+// ServerOption configures a fake Server
+type ServerOption func(*Server)
+
+// WithAPIKey sets the API key the server accepts for Link and NetInfo
+func WithAPIKey(key string) ServerOption {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithPublicAPIKey sets the public API key the server accepts for Toggle
+func WithPublicAPIKey(key string) ServerOption {
+	return func(s *Server) {
+		s.publicAPIKey = key
+	}
+}
+
+// WithOrganizationID sets the organization served by the Link endpoints
+func WithOrganizationID(id string) ServerOption {
+	return func(s *Server) {
+		s.organizationID = id
+	}
+}
+
+// Server is an in-process fake of the Toggle, NetInfo and Link APIs backed by
+// in-memory state
+type Server struct {
+	server         *httptest.Server
+	apiKey         string
+	publicAPIKey   string
+	organizationID string
+
+	mu       sync.Mutex
+	toggles  map[string]toggle.Evaluation
+	ipInfos  map[string]netinfo.IPInfo
+	codes    []*link.ShortCodeResponse
+	qrCodes  map[string][]link.QRCodeResponse
+	stats    map[string]link.GetCodeStatsResponse
+	faults   []*Fault
+	requests []RecordedRequest
+	nextID   int
+}
+
+// NewServer starts a fake Hyphen server. Call Close when done.
+func NewServer(options ...ServerOption) *Server {
+	s := &Server{
+		apiKey:         DefaultAPIKey,
+		publicAPIKey:   DefaultPublicAPIKey,
+		organizationID: DefaultOrganizationID,
+		toggles:        map[string]toggle.Evaluation{},
+		ipInfos:        map[string]netinfo.IPInfo{},
+		qrCodes:        map[string][]link.QRCodeResponse{},
+		stats:          map[string]link.GetCodeStatsResponse{},
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	codes := "/api/organizations/{org}/link/codes"
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /toggle/evaluate", s.handleEvaluate)
+	mux.HandleFunc("GET /ip/{ip}", s.handleGetIPInfo)
+	mux.HandleFunc("POST /ip", s.handleGetIPInfos)
+	mux.HandleFunc("POST "+codes, s.handleCreateShortCode)
+	mux.HandleFunc("GET "+codes, s.handleGetShortCodes)
+	mux.HandleFunc("GET "+codes+"/tags", s.handleGetTags)
+	mux.HandleFunc("GET "+codes+"/{code}", s.handleGetShortCode)
+	mux.HandleFunc("PATCH "+codes+"/{code}", s.handleUpdateShortCode)
+	mux.HandleFunc("DELETE "+codes+"/{code}", s.handleDeleteShortCode)
+	mux.HandleFunc("GET "+codes+"/{code}/stats", s.handleGetCodeStats)
+	mux.HandleFunc("POST "+codes+"/{code}/qrs", s.handleCreateQRCode)
+	mux.HandleFunc("GET "+codes+"/{code}/qrs", s.handleGetQRCodes)
+	mux.HandleFunc("GET "+codes+"/{code}/qrs/{id}", s.handleGetQRCode)
+	mux.HandleFunc("DELETE "+codes+"/{code}/qrs/{id}", s.handleDeleteQRCode)
+
+	s.server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the base URL of the server
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// LinkURI returns the Link codes URI template pointing at the server
+func (s *Server) LinkURI() string {
+	return s.server.URL + "/api/organizations/{organizationId}/link/codes/"
+}
+
+// Options returns hyphen options that point every service at the server with
+// matching credentials
+func (s *Server) Options() []hyphen.Option {
+	return []hyphen.Option{
+		hyphen.WithPublicAPIKey(s.publicAPIKey),
+		hyphen.WithApplicationID(DefaultApplicationID),
+		hyphen.WithAPIKey(s.apiKey),
+		hyphen.WithOrganizationID(s.organizationID),
+		hyphen.WithHorizonURLs([]string{s.server.URL}),
+		hyphen.WithNetInfoBaseURI(s.server.URL),
+		hyphen.WithLinkURIs([]string{s.LinkURI()}),
+	}
+}
+
+// Client returns a hyphen.Client configured against the server. Additional
+// options are applied after the defaults and can override them.
+func (s *Server) Client(options ...hyphen.Option) (*hyphen.Client, error) {
+	return hyphen.New(append(s.Options(), options...)...)
+}
+
+// WithHorizonURLsOf returns an option pointing Toggle at several fake servers
+// in order, for exercising failover
+func WithHorizonURLsOf(servers ...*Server) hyphen.Option {
+	urls := make([]string, 0, len(servers))
+	for _, s := range servers {
+		urls = append(urls, s.URL())
+	}
+	return hyphen.WithHorizonURLs(urls)
+}
+
+// WithLinkURIsOf returns an option pointing Link at several fake servers in
+// order, for exercising failover
+func WithLinkURIsOf(servers ...*Server) hyphen.Option {
+	uris := make([]string, 0, len(servers))
+	for _, s := range servers {
+		uris = append(uris, s.LinkURI())
+	}
+	return hyphen.WithLinkURIs(uris)
+}
+
+// SetToggle sets the value returned for a toggle. The type is derived from the
+// Go type of value.
+func (s *Server) SetToggle(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.toggles[key] = toggle.Evaluation{Key: key, Value: value, Type: toggleType(value), Reason: "default"}
+}
+
+// SetIPInfo sets the information returned for an IP address
+func (s *Server) SetIPInfo(info netinfo.IPInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ipInfos[info.IP] = info
+}
+
+// AddShortCode stores a short code, filling in an ID and creation time when
+// they are empty, and returns the stored value
+func (s *Server) AddShortCode(code link.ShortCodeResponse) link.ShortCodeResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.addShortCode(code)
+}
+
+// ShortCodes returns a copy of every stored short code
+func (s *Server) ShortCodes() []link.ShortCodeResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make([]link.ShortCodeResponse, 0, len(s.codes))
+	for _, code := range s.codes {
+		codes = append(codes, *code)
+	}
+	return codes
+}
+
+// SetCodeStats sets the statistics returned for a short code
+func (s *Server) SetCodeStats(code string, stats link.GetCodeStatsResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats[code] = stats
+}
+
+// InjectFault adds a fault applied to matching requests. Faults are evaluated
+// in the order they were added and the first match wins.
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns every request the server has received
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// middleware records requests and applies injected faults
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := readRequestBody(r)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, RecordedRequest{
+			Method:  r.Method,
+			URL:     r.URL.String(),
+			Headers: r.Header.Clone(),
+			Body:    body,
+		})
+		fault := s.matchFault(r)
+		s.mu.Unlock()
+
+		if fault != nil {
+			if fault.Latency > 0 {
+				select {
+				case <-time.After(fault.Latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if fault.MalformedJSON {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `{"malformed":`)
+				return
+			}
+			if fault.StatusCode != 0 {
+				writeError(w, fault.StatusCode, http.StatusText(fault.StatusCode))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// matchFault returns the first fault matching the request and consumes one of
+// its uses. Callers must hold s.mu.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, fault.Path) {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// authorize checks the x-api-key header against the expected key
+func authorize(w http.ResponseWriter, r *http.Request, key string) bool {
+	if r.Header.Get("x-api-key") != key {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return false
+	}
+	return true
+}
+
+// authorizeLink checks the API key and the organization in the path
+func (s *Server) authorizeLink(w http.ResponseWriter, r *http.Request) bool {
+	if !authorize(w, r, s.apiKey) {
+		return false
+	}
+	if r.PathValue("org") != s.organizationID {
+		writeError(w, http.StatusForbidden, "unknown organization")
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	if !authorize(w, r, s.publicAPIKey) {
+		return
+	}
+
+	s.mu.Lock()
+	toggles := make(map[string]toggle.Evaluation, len(s.toggles))
+	for key, evaluation := range s.toggles {
+		toggles[key] = evaluation
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, toggle.EvaluationResponse{Toggles: toggles})
+}
+
+func (s *Server) handleGetIPInfo(w http.ResponseWriter, r *http.Request) {
+	if !authorize(w, r, s.apiKey) {
+		return
+	}
+
+	info, infoErr := s.lookupIP(r.PathValue("ip"))
+	if infoErr != nil {
+		writeJSON(w, http.StatusBadRequest, infoErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleGetIPInfos(w http.ResponseWriter, r *http.Request) {
+	if !authorize(w, r, s.apiKey) {
+		return
+	}
+
+	var ips []string
+	if err := json.NewDecoder(r.Body).Decode(&ips); err != nil || len(ips) == 0 {
+		writeError(w, http.StatusBadRequest, "expected a non-empty array of IP addresses")
+		return
+	}
+
+	response := netinfo.IPInfosResponse{Data: make(netinfo.IPInfoResults, 0, len(ips))}
+	for _, ip := range ips {
+		info, infoErr := s.lookupIP(ip)
+		response.Data = append(response.Data, netinfo.IPInfoResult{IP: ip, Info: info, Error: infoErr})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// lookupIP returns stored information for an IP, generic information for any
+// other valid address, or an error result for invalid input
+func (s *Server) lookupIP(ip string) (*netinfo.IPInfo, *netinfo.IPInfoError) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, &netinfo.IPInfoError{IP: ip, Type: "error", ErrorMessage: "invalid IP address"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info, ok := s.ipInfos[ip]; ok {
+		return &info, nil
+	}
+
+	ipType := "ipv4"
+	if addr.Is6() {
+		ipType = "ipv6"
+	}
+	return &netinfo.IPInfo{IP: ip, Type: ipType}, nil
+}
+
+func (s *Server) handleCreateShortCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	var body struct {
+		LongURL string   `json:"long_url"`
+		Domain  string   `json:"domain"`
+		Code    string   `json:"code"`
+		Title   string   `json:"title"`
+		Tags    []string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.LongURL == "" || body.Domain == "" {
+		writeError(w, http.StatusBadRequest, "long_url and domain are required")
+		return
+	}
+
+	s.mu.Lock()
+	if body.Code != "" && s.findCode(body.Code, body.Domain) != nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "code already exists")
+		return
+	}
+	code := s.addShortCode(link.ShortCodeResponse{
+		Code:    body.Code,
+		LongURL: body.LongURL,
+		Domain:  body.Domain,
+		Title:   body.Title,
+		Tags:    body.Tags,
+	})
+	created := *code
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleGetShortCodes(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	title := strings.ToLower(query.Get("title"))
+	var tags []string
+	if query.Get("tags") != "" {
+		tags = strings.Split(query.Get("tags"), ",")
+	}
+	pageNum := queryInt(query.Get("pageNum"), 1)
+	pageSize := queryInt(query.Get("pageSize"), 100)
+
+	s.mu.Lock()
+	var matches []link.ShortCodeResponse
+	for _, code := range s.codes {
+		if title != "" && !strings.Contains(strings.ToLower(code.Title), title) {
+			continue
+		}
+		if !containsAll(code.Tags, tags) {
+			continue
+		}
+		matches = append(matches, *code)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, link.GetShortCodesResponse{
+		Total:    len(matches),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Data:     page(matches, pageNum, pageSize),
+	})
+}
+
+func (s *Server) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	tags := []string{}
+	for _, code := range s.codes {
+		for _, tag := range code.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	slices.Sort(tags)
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (s *Server) handleGetShortCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	code := s.findShortCode(r.PathValue("code"))
+	var found link.ShortCodeResponse
+	if code != nil {
+		found = *code
+	}
+	s.mu.Unlock()
+
+	if code == nil {
+		writeError(w, http.StatusNotFound, "short code not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+func (s *Server) handleUpdateShortCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	var update link.UpdateShortCodeOptions
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	code := s.findShortCode(r.PathValue("code"))
+	var updated link.ShortCodeResponse
+	if code != nil {
+		if update.LongURL != "" {
+			code.LongURL = update.LongURL
+		}
+		if update.Title != "" {
+			code.Title = update.Title
+		}
+		if update.Tags != nil {
+			code.Tags = append([]string(nil), update.Tags...)
+		}
+		updated = *code
+	}
+	s.mu.Unlock()
+
+	if code == nil {
+		writeError(w, http.StatusNotFound, "short code not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteShortCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	code := s.findShortCode(r.PathValue("code"))
+	if code != nil {
+		s.codes = slices.DeleteFunc(s.codes, func(c *link.ShortCodeResponse) bool { return c == code })
+		delete(s.qrCodes, code.ID)
+	}
+	s.mu.Unlock()
+
+	if code == nil {
+		writeError(w, http.StatusNotFound, "short code not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetCodeStats(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	code := s.findShortCode(r.PathValue("code"))
+	var stats link.GetCodeStatsResponse
+	if code != nil {
+		var ok bool
+		if stats, ok = s.stats[code.Code]; !ok {
+			stats = s.stats[code.ID]
+		}
+	}
+	s.mu.Unlock()
+
+	if code == nil {
+		writeError(w, http.StatusNotFound, "short code not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleCreateQRCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	var opts link.CreateQRCodeOptions
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	code := s.findShortCode(r.PathValue("code"))
+	var qr link.QRCodeResponse
+	if code != nil {
+		s.nextID++
+		qr = link.QRCodeResponse{
+			ID:     fmt.Sprintf("qr_%d", s.nextID),
+			Title:  opts.Title,
+			QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(placeholderPNG()),
+			QRLink: fmt.Sprintf("https://%s/%s", code.Domain, code.Code),
+		}
+		s.qrCodes[code.ID] = append(s.qrCodes[code.ID], qr)
+	}
+	s.mu.Unlock()
+
+	if code == nil {
+		writeError(w, http.StatusNotFound, "short code not found")
+		return
+	}
+	writeJSON(w, http.StatusCreated, qr)
+}
+
+func (s *Server) handleGetQRCodes(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	pageNum := queryInt(query.Get("pageNum"), 1)
+	pageSize := queryInt(query.Get("pageSize"), 100)
+
+	s.mu.Lock()
+	code := s.findShortCode(r.PathValue("code"))
+	var qrCodes []link.QRCodeResponse
+	if code != nil {
+		qrCodes = append(qrCodes, s.qrCodes[code.ID]...)
+	}
+	s.mu.Unlock()
+
+	if code == nil {
+		writeError(w, http.StatusNotFound, "short code not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, link.GetQRCodesResponse{
+		Total:    len(qrCodes),
+		PageNum:  pageNum,
+		PageSize: pageSize,
+		Data:     page(qrCodes, pageNum, pageSize),
+	})
+}
+
+func (s *Server) handleGetQRCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	qr, ok := s.findQRCode(r.PathValue("code"), r.PathValue("id"))
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "QR code not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, qr)
+}
+
+func (s *Server) handleDeleteQRCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeLink(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	_, ok := s.findQRCode(r.PathValue("code"), r.PathValue("id"))
+	if ok {
+		code := s.findShortCode(r.PathValue("code"))
+		s.qrCodes[code.ID] = slices.DeleteFunc(s.qrCodes[code.ID], func(qr link.QRCodeResponse) bool {
+			return qr.ID == r.PathValue("id")
+		})
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "QR code not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addShortCode stores a short code. Callers must hold s.mu.
+func (s *Server) addShortCode(code link.ShortCodeResponse) *link.ShortCodeResponse {
+	s.nextID++
+	if code.ID == "" {
+		code.ID = fmt.Sprintf("code_%d", s.nextID)
+	}
+	if code.Code == "" {
+		code.Code = fmt.Sprintf("c%d", s.nextID)
+	}
+	if code.CreatedAt == "" {
+		code.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	if code.OrganizationID.ID == "" {
+		code.OrganizationID = link.OrganizationID{ID: s.organizationID}
+	}
+	code.Tags = append([]string(nil), code.Tags...)
+
+	stored := &code
+	s.codes = append(s.codes, stored)
+	return stored
+}
+
+// findShortCode looks a short code up by ID, as the API's /codes/{id} routes
+// do. Callers must hold s.mu.
+func (s *Server) findShortCode(id string) *link.ShortCodeResponse {
+	for _, code := range s.codes {
+		if code.ID == id {
+			return code
+		}
+	}
+	return nil
+}
+
+// findCode looks a short code up by its code on domain. Callers must hold s.mu.
+func (s *Server) findCode(code, domain string) *link.ShortCodeResponse {
+	for _, stored := range s.codes {
+		if stored.Code == code && stored.Domain == domain {
+			return stored
+		}
+	}
+	return nil
+}
+
+// findQRCode looks a QR code up by short code ID and QR code ID. Callers must
+// hold s.mu.
+func (s *Server) findQRCode(id, qrID string) (link.QRCodeResponse, bool) {
+	code := s.findShortCode(id)
+	if code == nil {
+		return link.QRCodeResponse{}, false
+	}
+	for _, qr := range s.qrCodes[code.ID] {
+		if qr.ID == qrID {
+			return qr, true
+		}
+	}
+	return link.QRCodeResponse{}, false
+}
+
+// toggleType maps a Go value to a toggle type name
+func toggleType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int, int32, int64, float32, float64:
+		return "number"
+	default:
+		return "object"
+	}
+}
+
+// placeholderPNG renders a small two-tone image standing in for a QR code
+func placeholderPNG() []byte {
+	img := image.NewGray(image.Rect(0, 0, 21, 21))
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 21; x++ {
+			if (x+y)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// page returns one page of items using 1-based page numbers
+func page[T any](items []T, pageNum, pageSize int) []T {
+	start := (pageNum - 1) * pageSize
+	if start >= len(items) {
+		return []T{}
+	}
+	end := min(start+pageSize, len(items))
+	return items[start:end]
+}
+
+// containsAll reports whether every wanted value is present in values
+func containsAll(values, wanted []string) bool {
+	for _, w := range wanted {
+		if !slices.Contains(values, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryInt parses a positive integer query parameter
+func queryInt(value string, fallback int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,383 @@
+package hyphentest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Hyphen/go-sdk/pkg/link"
+	"github.com/Hyphen/go-sdk/pkg/netinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func newServer(t *testing.T, options ...ServerOption) *Server {
+	t.Helper()
+	server := NewServer(options...)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestServerToggle(t *testing.T) {
+	t.Run("evaluates_configured_toggles", func(t *testing.T) {
+		server := newServer(t)
+		server.SetToggle("theFlag", true)
+		server.SetToggle("theMessage", "hello")
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		assert.True(t, client.Toggle.GetBoolean(context.Background(), "theFlag", false, nil))
+		assert.Equal(t, "hello", client.Toggle.GetString(context.Background(), "theMessage", "", nil))
+		assert.Equal(t, 3.0, client.Toggle.GetNumber(context.Background(), "missing", 3.0, nil))
+	})
+
+	t.Run("fails_over_to_the_next_horizon_url_when_one_returns_5xx", func(t *testing.T) {
+		failing := newServer(t)
+		failing.InjectFault(Fault{StatusCode: http.StatusServiceUnavailable})
+		healthy := newServer(t)
+		healthy.SetToggle("theFlag", true)
+		client, err := healthy.Client(WithHorizonURLsOf(failing, healthy))
+		require.NoError(t, err)
+
+		result := client.Toggle.GetBoolean(context.Background(), "theFlag", false, nil)
+
+		assert.True(t, result)
+		assert.Len(t, failing.Requests(), 1)
+	})
+
+	t.Run("returns_the_default_value_on_malformed_json", func(t *testing.T) {
+		server := newServer(t)
+		server.SetToggle("theFlag", true)
+		server.InjectFault(Fault{Path: "/toggle", MalformedJSON: true})
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		assert.False(t, client.Toggle.GetBoolean(context.Background(), "theFlag", false, nil))
+	})
+}
+
+func TestServerNetInfo(t *testing.T) {
+	t.Run("returns_configured_and_generic_ip_info", func(t *testing.T) {
+		server := newServer(t)
+		server.SetIPInfo(netinfo.IPInfo{IP: "8.8.8.8", Type: "ipv4", Location: netinfo.Location{Country: "US"}})
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		configured, err := client.NetInfo.GetIPInfo(context.Background(), "8.8.8.8")
+		require.NoError(t, err)
+		generic, err := client.NetInfo.GetIPInfo(context.Background(), "2001:db8::1")
+		require.NoError(t, err)
+
+		assert.Equal(t, "US", configured.Location.Country)
+		assert.Equal(t, "ipv6", generic.Type)
+	})
+
+	t.Run("returns_bulk_results_in_order", func(t *testing.T) {
+		server := newServer(t)
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		results, err := client.NetInfo.GetIPInfos(context.Background(), []string{"1.1.1.1", "not-an-ip", "::1"})
+		infos, failures := results.Split()
+
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.NoError(t, results[0].Err())
+		assert.Equal(t, "ipv4", results[0].Info.Type)
+		assert.EqualError(t, results[1].Err(), "ip info for not-an-ip: invalid IP address")
+		assert.Nil(t, results[1].Info)
+		assert.Equal(t, "::1", results[2].IP)
+		assert.Equal(t, []string{"1.1.1.1", "::1"}, []string{infos[0].IP, infos[1].IP})
+		assert.Equal(t, []netinfo.IPInfoError{{IP: "not-an-ip", Type: "error", ErrorMessage: "invalid IP address"}}, failures)
+	})
+
+	t.Run("looks_up_distinct_ips_in_chunks", func(t *testing.T) {
+		server := newServer(t)
+		client, err := server.Client()
+		require.NoError(t, err)
+		var ips []string
+		for i := range 250 {
+			ips = append(ips, fmt.Sprintf("10.0.0.%d", i%120))
+		}
+		ips = append(ips, "not-an-ip", "")
+
+		var found, failed []string
+		for result := range client.NetInfo.IPInfos(context.Background(), ips, &netinfo.BulkOptions{ChunkSize: 50, Concurrency: 2}) {
+			if result.Err() != nil {
+				failed = append(failed, result.IP)
+			} else {
+				found = append(found, result.Info.IP)
+			}
+		}
+
+		assert.Len(t, found, 120)
+		assert.Equal(t, []string{"not-an-ip"}, failed)
+		assert.Len(t, server.Requests(), 3)
+	})
+
+	t.Run("retries_failed_chunks", func(t *testing.T) {
+		server := newServer(t)
+		server.InjectFault(Fault{Path: "/ip", StatusCode: http.StatusServiceUnavailable, Times: 2})
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		var results []netinfo.BulkResult
+		for result := range client.NetInfo.StreamIPInfos(context.Background(), []string{"1.1.1.1", "8.8.8.8"}, &netinfo.BulkOptions{RetryDelay: time.Millisecond}) {
+			results = append(results, result)
+		}
+
+		require.Len(t, results, 2)
+		assert.NoError(t, results[0].Err())
+		assert.NoError(t, results[1].Err())
+		assert.Len(t, server.Requests(), 3)
+	})
+
+	t.Run("reports_chunks_that_keep_failing", func(t *testing.T) {
+		server := newServer(t)
+		n, err := netinfo.New(netinfo.WithAPIKey("wrongKey"), netinfo.WithBaseURI(server.URL()))
+		require.NoError(t, err)
+
+		var results []netinfo.BulkResult
+		for result := range n.IPInfos(context.Background(), []string{"1.1.1.1", "8.8.8.8"}, nil) {
+			results = append(results, result)
+		}
+
+		require.Len(t, results, 2)
+		assert.EqualError(t, results[0].Err(), "failed to fetch ip infos: HTTP 401: 401 Unauthorized")
+		assert.Len(t, server.Requests(), 1, "client errors are not retried")
+	})
+
+	t.Run("stops_when_the_loop_breaks", func(t *testing.T) {
+		server := newServer(t)
+		client, err := server.Client()
+		require.NoError(t, err)
+		ips := []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "4.4.4.4"}
+
+		for range client.NetInfo.IPInfos(context.Background(), ips, &netinfo.BulkOptions{ChunkSize: 1, Concurrency: 1}) {
+			break
+		}
+
+		assert.Less(t, len(server.Requests()), len(ips))
+	})
+
+	t.Run("caches_lookups_and_shares_concurrent_ones", func(t *testing.T) {
+		server := newServer(t)
+		// Requests wait until every caller joined the cache, so the lookup
+		// cannot finish before the last one arrives
+		release := make(chan struct{})
+		gated := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			<-release
+			return http.DefaultTransport.RoundTrip(r)
+		})}
+		n, err := netinfo.New(netinfo.WithAPIKey(DefaultAPIKey), netinfo.WithBaseURI(server.URL()),
+			netinfo.WithHTTPClient(gated), netinfo.WithCache(netinfo.CacheOptions{}))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				info, err := n.GetIPInfo(context.Background(), "::ffff:1.1.1.1")
+				if assert.NoError(t, err) {
+					assert.Equal(t, "1.1.1.1", info.IP)
+				}
+			}()
+		}
+		require.Eventually(t, func() bool {
+			stats := n.CacheStats()
+			return stats.Misses+stats.Coalesced == 8
+		}, 5*time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		results, err := n.GetIPInfos(context.Background(), []string{"1.1.1.1", "8.8.8.8"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "1.1.1.1", results[0].Info.IP)
+		assert.Len(t, server.Requests(), 2)
+		stats := n.CacheStats()
+		assert.Equal(t, uint64(2), stats.Misses)
+		assert.Equal(t, uint64(7), stats.Coalesced)
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, 2, stats.Entries)
+	})
+
+	t.Run("rejects_an_invalid_api_key", func(t *testing.T) {
+		server := newServer(t)
+		n, err := netinfo.New(netinfo.WithAPIKey("wrongKey"), netinfo.WithBaseURI(server.URL()))
+		require.NoError(t, err)
+
+		_, err = n.GetIPInfo(context.Background(), "1.1.1.1")
+
+		assert.EqualError(t, err, "failed to fetch ip info: HTTP 401: 401 Unauthorized")
+	})
+
+	t.Run("honors_context_deadlines_when_latency_is_injected", func(t *testing.T) {
+		server := newServer(t)
+		server.InjectFault(Fault{Latency: time.Second})
+		client, err := server.Client()
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err = client.NetInfo.GetIPInfo(ctx, "1.1.1.1")
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestServerLink(t *testing.T) {
+	t.Run("fails_over_to_the_next_link_uri_when_one_returns_5xx", func(t *testing.T) {
+		failing := newServer(t)
+		failing.InjectFault(Fault{StatusCode: http.StatusBadGateway})
+		healthy := newServer(t)
+		stored := healthy.AddShortCode(link.ShortCodeResponse{Code: "theCode", LongURL: "https://hyphen.ai", Domain: "h4n.link"})
+		client, err := healthy.Client(WithLinkURIsOf(failing, healthy))
+		require.NoError(t, err)
+
+		code, err := client.Link.GetShortCode(context.Background(), stored.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://hyphen.ai", code.LongURL)
+		assert.Len(t, failing.Requests(), 1)
+		assert.False(t, client.Link.Health()[0].Healthy)
+	})
+
+	t.Run("supports_the_short_code_lifecycle", func(t *testing.T) {
+		server := newServer(t)
+		client, err := server.Client()
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		created, err := client.Link.CreateShortCode(ctx, "https://hyphen.ai", "h4n.link", &link.CreateShortCodeOptions{
+			Code:  "theCode",
+			Title: "theTitle",
+			Tags:  []string{"tag1"},
+		})
+		require.NoError(t, err)
+		fetched, err := client.Link.GetShortCode(ctx, created.ID)
+		require.NoError(t, err)
+		updated, err := client.Link.UpdateShortCode(ctx, created.ID, &link.UpdateShortCodeOptions{Tags: []string{"tag2"}})
+		require.NoError(t, err)
+		tags, err := client.Link.GetTags(ctx)
+		require.NoError(t, err)
+		require.NoError(t, client.Link.DeleteShortCode(ctx, created.ID))
+		_, err = client.Link.GetShortCode(ctx, created.ID)
+
+		assert.Equal(t, "theCode", created.Code)
+		assert.Equal(t, created, fetched)
+		assert.Equal(t, []string{"tag2"}, updated.Tags)
+		assert.Equal(t, []string{"tag2"}, tags)
+		assert.EqualError(t, err, "failed to get short code: HTTP 404: 404 Not Found")
+	})
+
+	t.Run("paginates_and_filters_short_codes", func(t *testing.T) {
+		server := newServer(t)
+		for _, title := range []string{"alpha", "beta", "alphabet"} {
+			server.AddShortCode(link.ShortCodeResponse{LongURL: "https://hyphen.ai", Domain: "h4n.link", Title: title})
+		}
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		page, err := client.Link.GetShortCodes(context.Background(), "alpha", nil, 2, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, "alphabet", page.Data[0].Title)
+	})
+
+	t.Run("ensures_one_short_code_under_concurrent_callers", func(t *testing.T) {
+		server := newServer(t)
+		client, err := server.Client()
+		require.NoError(t, err)
+		opts := &link.CreateShortCodeOptions{Title: "theTitle", Tags: []string{"pipeline"}}
+
+		var wg sync.WaitGroup
+		actions := make([]link.EnsureAction, 8)
+		for i := range actions {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := client.Link.EnsureShortCode(context.Background(), "https://hyphen.ai", "h4n.link", opts)
+				if assert.NoError(t, err) {
+					actions[i] = result.Action
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, server.ShortCodes(), 1)
+		assert.Equal(t, 1, countOf(actions, link.EnsureCreated))
+		assert.Equal(t, 7, countOf(actions, link.EnsureUnchanged))
+	})
+
+	t.Run("ensures_a_custom_code_without_duplicating_it", func(t *testing.T) {
+		server := newServer(t)
+		client, err := server.Client()
+		require.NoError(t, err)
+		opts := &link.CreateShortCodeOptions{Code: "theCode"}
+
+		first, err := client.Link.EnsureShortCode(context.Background(), "https://hyphen.ai", "h4n.link", opts)
+		require.NoError(t, err)
+		second, err := client.Link.EnsureShortCode(context.Background(), "https://hyphen.ai", "h4n.link", opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, link.EnsureCreated, first.Action)
+		assert.Equal(t, link.EnsureUnchanged, second.Action)
+		assert.Len(t, server.ShortCodes(), 1)
+	})
+
+	t.Run("supports_the_qr_code_lifecycle", func(t *testing.T) {
+		server := newServer(t)
+		code := server.AddShortCode(link.ShortCodeResponse{Code: "theCode", LongURL: "https://hyphen.ai", Domain: "h4n.link"})
+		client, err := server.Client()
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		qr, err := client.Link.CreateQRCode(ctx, code.ID, &link.CreateQRCodeOptions{Title: "theQR"})
+		require.NoError(t, err)
+		list, err := client.Link.GetQRCodes(ctx, code.ID, 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, client.Link.DeleteQRCode(ctx, code.ID, qr.ID))
+		_, err = client.Link.GetQRCode(ctx, code.ID, qr.ID)
+
+		assert.Equal(t, "https://h4n.link/theCode", qr.QRLink)
+		assert.Equal(t, 1, list.Total)
+		assert.Error(t, err)
+	})
+
+	t.Run("injects_a_limited_number_of_faults", func(t *testing.T) {
+		server := newServer(t)
+		server.InjectFault(Fault{Method: http.MethodGet, StatusCode: http.StatusInternalServerError, Times: 1})
+		client, err := server.Client()
+		require.NoError(t, err)
+
+		_, first := client.Link.GetTags(context.Background())
+		_, second := client.Link.GetTags(context.Background())
+
+		assert.Error(t, first)
+		assert.NoError(t, second)
+	})
+}
+
+// countOf counts the occurrences of value in values
+func countOf[T comparable](values []T, value T) int {
+	n := 0
+	for _, v := range values {
+		if v == value {
+			n++
+		}
+	}
+	return n
+}
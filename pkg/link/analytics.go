@@ -0,0 +1,300 @@
+package link
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Dimensions of analytics rows
+const (
+	DimensionTotal    = "total"    // All clicks of the day
+	DimensionReferrer = "referrer" // Clicks from one referring URL
+	DimensionCountry  = "country"  // Clicks from one country
+	DimensionDevice   = "device"   // Clicks from one device type
+)
+
+// AnalyticsRow is the clicks of one short code on one day, either in total or
+// for one referrer, country or device
+type AnalyticsRow struct {
+	Code      string    `json:"code"` // ID of the short code
+	Date      time.Time `json:"-"`    // Midnight UTC of the day
+	Dimension string    `json:"dimension"`
+	Referrer  string    `json:"referrer,omitempty"`
+	Country   string    `json:"country,omitempty"`
+	Device    string    `json:"device,omitempty"`
+	Total     int       `json:"total"`
+	Unique    *int      `json:"unique,omitempty"` // Only reported for totals and countries
+}
+
+// MarshalJSON writes the date as a plain date
+func (r AnalyticsRow) MarshalJSON() ([]byte, error) {
+	type row AnalyticsRow
+	return json.Marshal(struct {
+		Date string `json:"date"`
+		row
+	}{Date: r.Date.Format(dateLayout), row: row(r)})
+}
+
+// AnalyticsState remembers the last day exported for each short code, so an
+// incremental export only fetches newer days. Marshal it to JSON to keep it
+// between runs.
+type AnalyticsState struct {
+	mu       sync.Mutex
+	Exported map[string]string `json:"exported"` // Short code ID to its last exported day, as 2006-01-02
+}
+
+// LastExported returns the last day exported for a short code ID
+func (s *AnalyticsState) LastExported(code string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day, err := time.Parse(dateLayout, s.Exported[code])
+	return day, err == nil
+}
+
+func (s *AnalyticsState) markExported(code string, day time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Exported == nil {
+		s.Exported = map[string]string{}
+	}
+	s.Exported[code] = day.Format(dateLayout)
+}
+
+// AnalyticsOptions configures ExportAnalytics and CollectAnalytics
+type AnalyticsOptions struct {
+	Codes []string      // IDs of the short codes to report on, like code_1234567890
+	Tags  []string      // Also report on every code carrying all of these tags
+	Start time.Time     // First day to report, required unless State has every code
+	End   time.Time     // Last day to report, defaults to today
+	Batch *BatchOptions // Concurrency and rate limit of the statistics requests
+
+	// State makes the export incremental: days up to the last exported day of
+	// a code are skipped, and only complete days, before today, are reported
+	// and recorded. ExportAnalytics updates it once the rows are written.
+	State *AnalyticsState
+
+	Format Format // FormatCSV, the default, or FormatJSONLines
+}
+
+// analyticsDay is one statistics request
+type analyticsDay struct {
+	code string
+	day  time.Time
+	rows []AnalyticsRow
+	err  error
+}
+
+// CollectAnalytics fetches the statistics of each code for each day of the
+// range, one request per code and day, and returns them as rows ordered by
+// code and date. Days that fail are left out and their errors joined; with a
+// State they are fetched again next time. The State is not updated.
+func (l *Link) CollectAnalytics(ctx context.Context, opts *AnalyticsOptions) ([]AnalyticsRow, error) {
+	rows, _, err := l.collectAnalytics(ctx, opts)
+	return rows, err
+}
+
+// ExportAnalytics writes the rows of CollectAnalytics to w as CSV or JSON
+// Lines and returns how many rows were written. With a State the last
+// exported day of each code is advanced after writing, up to the first day
+// that failed.
+func (l *Link) ExportAnalytics(ctx context.Context, w io.Writer, opts *AnalyticsOptions) (int, error) {
+	if opts == nil {
+		opts = &AnalyticsOptions{}
+	}
+	if opts.Format != "" && opts.Format != FormatCSV && opts.Format != FormatJSONLines {
+		err := fmt.Errorf("unsupported format %q", opts.Format)
+		l.emitError(err)
+		return 0, err
+	}
+
+	rows, exported, fetchErr := l.collectAnalytics(ctx, opts)
+	if rows == nil && fetchErr != nil {
+		return 0, fetchErr
+	}
+
+	var err error
+	if opts.Format == FormatJSONLines {
+		err = writeAnalyticsJSONLines(w, rows)
+	} else {
+		err = writeAnalyticsCSV(w, rows)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to write analytics: %w", err)
+		l.emitError(err)
+		return 0, err
+	}
+
+	if opts.State != nil {
+		for code, day := range exported {
+			opts.State.markExported(code, day)
+		}
+	}
+	return len(rows), fetchErr
+}
+
+// collectAnalytics fetches the rows and reports, for incremental exports, the
+// last day of each code up to which every day succeeded
+func (l *Link) collectAnalytics(ctx context.Context, opts *AnalyticsOptions) ([]AnalyticsRow, map[string]time.Time, error) {
+	if opts == nil {
+		opts = &AnalyticsOptions{}
+	}
+
+	codes, err := l.analyticsCodes(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	end := opts.End
+	if end.IsZero() {
+		end = today
+	}
+	end = end.UTC().Truncate(24 * time.Hour)
+	if opts.State != nil && !end.Before(today) {
+		// Today is still collecting clicks
+		end = today.AddDate(0, 0, -1)
+	}
+
+	var days []analyticsDay
+	for _, code := range codes {
+		start := opts.Start.UTC().Truncate(24 * time.Hour)
+		if opts.State != nil {
+			if last, ok := opts.State.LastExported(code); ok && !last.Before(start) {
+				start = last.AddDate(0, 0, 1)
+			}
+		}
+		if start.IsZero() {
+			err := &ValidationError{Fields: []FieldError{{Field: "start", Message: "is required"}}}
+			l.emitError(err)
+			return nil, nil, err
+		}
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			days = append(days, analyticsDay{code: code, day: day})
+		}
+	}
+
+	indexes := make([]int, len(days))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	runParallel(ctx, indexes, opts.Batch, func(i int) {
+		d := &days[i]
+		stats, err := l.GetCodeStats(ctx, d.code, d.day, d.day.Add(24*time.Hour-time.Second))
+		if err != nil {
+			d.err = fmt.Errorf("%s on %s: %w", d.code, d.day.Format(dateLayout), err)
+			return
+		}
+		d.rows = analyticsRows(d.code, d.day, stats)
+	}, func(i int, err error) {
+		days[i].err = err
+	})
+
+	rows := []AnalyticsRow{}
+	exported := map[string]time.Time{}
+	failed := map[string]bool{}
+	var errs []error
+	for _, d := range days {
+		if d.err != nil {
+			errs = append(errs, d.err)
+			failed[d.code] = true
+			continue
+		}
+		rows = append(rows, d.rows...)
+		if !failed[d.code] {
+			exported[d.code] = d.day
+		}
+	}
+	return rows, exported, errors.Join(errs...)
+}
+
+// analyticsCodes lists the IDs of the codes to report on, in order and
+// without duplicates
+func (l *Link) analyticsCodes(ctx context.Context, opts *AnalyticsOptions) ([]string, error) {
+	codes := slices.Clone(opts.Codes)
+	if len(opts.Tags) > 0 {
+		for code, err := range l.ShortCodes(ctx, &ShortCodesOptions{Tags: opts.Tags}) {
+			if err != nil {
+				return nil, err
+			}
+			codes = append(codes, code.ID)
+		}
+	}
+
+	seen := map[string]bool{}
+	return slices.DeleteFunc(codes, func(code string) bool {
+		duplicate := seen[code]
+		seen[code] = true
+		return duplicate
+	}), nil
+}
+
+// analyticsRows normalizes the statistics of one code and day. Locations are
+// summed per country.
+func analyticsRows(code string, day time.Time, stats *GetCodeStatsResponse) []AnalyticsRow {
+	unique := stats.Clicks.Unique
+	rows := []AnalyticsRow{{Code: code, Date: day, Dimension: DimensionTotal, Total: stats.Clicks.Total, Unique: &unique}}
+
+	for _, referral := range stats.Referrals {
+		rows = append(rows, AnalyticsRow{Code: code, Date: day, Dimension: DimensionReferrer, Referrer: referral.URL, Total: referral.Total})
+	}
+
+	var countries []string
+	byCountry := map[string]*AnalyticsRow{}
+	for _, location := range stats.Locations {
+		row := byCountry[location.Country]
+		if row == nil {
+			countries = append(countries, location.Country)
+			row = &AnalyticsRow{Code: code, Date: day, Dimension: DimensionCountry, Country: location.Country, Unique: new(int)}
+			byCountry[location.Country] = row
+		}
+		row.Total += location.Total
+		*row.Unique += location.Unique
+	}
+	for _, country := range countries {
+		rows = append(rows, *byCountry[country])
+	}
+
+	for _, device := range stats.Devices {
+		rows = append(rows, AnalyticsRow{Code: code, Date: day, Dimension: DimensionDevice, Device: device.Name, Total: device.Total})
+	}
+	return rows
+}
+
+// writeAnalyticsCSV writes rows as CSV with a header row
+func writeAnalyticsCSV(w io.Writer, rows []AnalyticsRow) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"code", "date", "dimension", "referrer", "country", "device", "total", "unique"})
+	for _, row := range rows {
+		writer.Write([]string{
+			escapeFormula(row.Code),
+			row.Date.Format(dateLayout),
+			row.Dimension,
+			escapeFormula(row.Referrer),
+			escapeFormula(row.Country),
+			escapeFormula(row.Device),
+			strconv.Itoa(row.Total),
+			optionalInt(row.Unique),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeAnalyticsJSONLines writes one JSON object per row
+func writeAnalyticsJSONLines(w io.Writer, rows []AnalyticsRow) error {
+	encoder := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package link
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAnalytics(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	// newLink answers statistics requests with clicks derived from the day and
+	// records the code and day of each request
+	newLink := func(requests *[]string) *Link {
+		codes := newFakeCodes(
+			ShortCodeResponse{ID: "tagged", Code: "taggedCode", Tags: []string{"campaign"}},
+			ShortCodeResponse{ID: "a", Code: "aCode"},
+		)
+		codes.stats = func(id string, start time.Time) (*GetCodeStatsResponse, error) {
+			if requests != nil {
+				*requests = append(*requests, id+" "+start.Format(dateLayout))
+			}
+			if id == "broken" && start.Day() == 2 {
+				return nil, errors.New("connection reset")
+			}
+			return &GetCodeStatsResponse{
+				Clicks:    ClicksStats{Total: start.Day() * 10, Unique: start.Day()},
+				Referrals: []ReferralStats{{URL: "https://news.example.com", Total: 4}},
+				Locations: []LocationStats{
+					{Country: "US", City: "Austin", Total: 3, Unique: 2},
+					{Country: "DE", Total: 2, Unique: 1},
+					{Country: "US", City: "Boston", Total: 1, Unique: 1},
+				},
+				Devices: []DeviceStats{{Name: "mobile", Total: 6}},
+			}, nil
+		}
+		return codes.link()
+	}
+
+	t.Run("writes_normalized_csv_rows", func(t *testing.T) {
+		var requests []string
+		var buf bytes.Buffer
+
+		n, err := newLink(&requests).ExportAnalytics(context.Background(), &buf, &AnalyticsOptions{
+			Codes: []string{"a"},
+			Start: day(1),
+			End:   day(1),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, n)
+		assert.Equal(t, `code,date,dimension,referrer,country,device,total,unique
+a,2025-03-01,total,,,,10,1
+a,2025-03-01,referrer,https://news.example.com,,,4,
+a,2025-03-01,country,,US,,4,3
+a,2025-03-01,country,,DE,,2,1
+a,2025-03-01,device,,,mobile,6,
+`, buf.String())
+	})
+
+	t.Run("escapes_referrers_that_look_like_formulas", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		err := writeAnalyticsCSV(&buf, []AnalyticsRow{
+			{Code: "a", Date: day(1), Dimension: DimensionReferrer, Referrer: "=HYPERLINK(\"https://evil.test\")", Total: 1},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, `code,date,dimension,referrer,country,device,total,unique
+a,2025-03-01,referrer,"'=HYPERLINK(""https://evil.test"")",,,1,
+`, buf.String())
+	})
+
+	t.Run("writes_json_lines_for_codes_and_tags", func(t *testing.T) {
+		var requests []string
+		var buf bytes.Buffer
+
+		n, err := newLink(&requests).ExportAnalytics(context.Background(), &buf, &AnalyticsOptions{
+			Codes:  []string{"a"},
+			Tags:   []string{"campaign"},
+			Start:  day(1),
+			End:    day(2),
+			Format: FormatJSONLines,
+			Batch:  &BatchOptions{Concurrency: 3},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 20, n)
+		assert.ElementsMatch(t, []string{"a 2025-03-01", "a 2025-03-02", "tagged 2025-03-01", "tagged 2025-03-02"}, requests)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, `{"date":"2025-03-01","code":"a","dimension":"total","total":10,"unique":1}`, lines[0])
+		assert.Equal(t, `{"date":"2025-03-02","code":"a","dimension":"device","device":"mobile","total":6}`, lines[9])
+		assert.Contains(t, lines[10], `"code":"tagged"`)
+	})
+
+	t.Run("exports_only_new_complete_days_incrementally", func(t *testing.T) {
+		var requests []string
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		state := &AnalyticsState{}
+		opts := &AnalyticsOptions{Codes: []string{"a"}, Start: today.AddDate(0, 0, -3), State: state}
+		link := newLink(&requests)
+
+		_, err := link.ExportAnalytics(context.Background(), &bytes.Buffer{}, opts)
+		require.NoError(t, err)
+		last, ok := state.LastExported("a")
+		require.True(t, ok)
+		assert.Equal(t, today.AddDate(0, 0, -1), last, "today is not complete yet")
+		assert.Len(t, requests, 3)
+
+		// The state survives a round trip through JSON
+		data, err := json.Marshal(state)
+		require.NoError(t, err)
+		opts.State = &AnalyticsState{}
+		require.NoError(t, json.Unmarshal(data, opts.State))
+
+		requests = nil
+		n, err := link.ExportAnalytics(context.Background(), &bytes.Buffer{}, opts)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Empty(t, requests)
+	})
+
+	t.Run("stops_the_state_at_the_first_failed_day", func(t *testing.T) {
+		var requests []string
+		state := &AnalyticsState{Exported: map[string]string{"a": "2025-03-01"}}
+		var buf bytes.Buffer
+
+		n, err := newLink(&requests).ExportAnalytics(context.Background(), &buf, &AnalyticsOptions{
+			Codes: []string{"a", "broken"},
+			Start: day(1),
+			End:   day(3),
+			State: state,
+		})
+
+		assert.ErrorContains(t, err, "broken on 2025-03-02: failed to get code stats")
+		assert.Equal(t, 20, n, "a on the 2nd and 3rd, broken on the 1st and 3rd")
+		assert.Equal(t, map[string]string{"a": "2025-03-03", "broken": "2025-03-01"}, state.Exported)
+		assert.False(t, slices.Contains(requests, "a 2025-03-01"))
+	})
+
+	t.Run("requires_a_start", func(t *testing.T) {
+		_, err := newLink(nil).CollectAnalytics(context.Background(), &AnalyticsOptions{Codes: []string{"a"}})
+
+		assert.EqualError(t, err, "invalid request: start is required")
+	})
+}
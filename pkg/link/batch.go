@@ -0,0 +1,185 @@
+package link
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+)
+
+// defaultBatchConcurrency is the number of workers used when none is configured
+const defaultBatchConcurrency = 4
+
+// ShortCodeRequest describes one short code to create in a batch
+type ShortCodeRequest struct {
+	LongURL string                  `json:"long_url"`
+	Domain  string                  `json:"domain"`
+	Options *CreateShortCodeOptions `json:"options,omitempty"`
+}
+
+// BatchOptions configures batch operations
+type BatchOptions struct {
+	Concurrency    int     // Number of concurrent workers, defaults to 4
+	RateLimit      float64 // Maximum requests per second across all workers, zero for unlimited
+	RateLimitBurst int     // Burst size for RateLimit
+}
+
+// BatchResult is the outcome of one entry of a batch
+type BatchResult struct {
+	Index     int                // Position of the entry in the input
+	Request   ShortCodeRequest   // The entry that was submitted
+	ShortCode *ShortCodeResponse // The created short code, nil on failure
+	Err       error              // Why the entry failed, nil on success
+}
+
+// BatchResults holds the results of a batch in input order
+type BatchResults []BatchResult
+
+// Succeeded returns the results that created a short code
+func (r BatchResults) Succeeded() BatchResults {
+	var succeeded BatchResults
+	for _, result := range r {
+		if result.Err == nil {
+			succeeded = append(succeeded, result)
+		}
+	}
+	return succeeded
+}
+
+// Failed returns the results that did not create a short code
+func (r BatchResults) Failed() BatchResults {
+	var failed BatchResults
+	for _, result := range r {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err summarizes the failures of a batch, or returns nil if every entry succeeded
+func (r BatchResults) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: len(failed), Total: len(r), First: failed[0].Err}
+}
+
+// BatchError reports that some entries of a batch failed
+type BatchError struct {
+	Failed int
+	Total  int
+	First  error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d batch entries failed, first error: %v", e.Failed, e.Total, e.First)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.First
+}
+
+// CreateShortCodes creates many short codes concurrently. Results are returned
+// in input order whether or not they succeeded; the error is a *BatchError when
+// any entry failed. Entries not started before ctx is done fail with the
+// context's error, so the batch can be completed with ResumeShortCodes.
+func (l *Link) CreateShortCodes(ctx context.Context, requests []ShortCodeRequest, opts *BatchOptions) (BatchResults, error) {
+	results := make(BatchResults, len(requests))
+	indexes := make([]int, len(requests))
+	for i, request := range requests {
+		results[i] = BatchResult{Index: i, Request: request}
+		indexes[i] = i
+	}
+
+	l.runBatch(ctx, results, indexes, opts)
+	return results, results.Err()
+}
+
+// ResumeShortCodes retries the failed entries of a previous batch and returns
+// the merged results in the original input order. Entries that already
+// succeeded are not sent again.
+func (l *Link) ResumeShortCodes(ctx context.Context, previous BatchResults, opts *BatchOptions) (BatchResults, error) {
+	results := make(BatchResults, len(previous))
+	copy(results, previous)
+
+	var indexes []int
+	for i, result := range results {
+		if result.Err != nil {
+			results[i].Err = nil
+			indexes = append(indexes, i)
+		}
+	}
+
+	l.runBatch(ctx, results, indexes, opts)
+	return results, results.Err()
+}
+
+// runBatch creates the short codes at the given result indexes with a bounded
+// worker pool, writing each outcome back into results
+func (l *Link) runBatch(ctx context.Context, results BatchResults, indexes []int, opts *BatchOptions) {
+	runParallel(ctx, indexes, opts, func(i int) {
+		result := &results[i]
+		result.ShortCode, result.Err = l.CreateShortCode(ctx, result.Request.LongURL, result.Request.Domain, result.Request.Options)
+	}, func(i int, err error) {
+		results[i].Err = err
+	})
+}
+
+// runParallel calls run for each index with a bounded, optionally rate limited
+// worker pool. Indexes that are not started because ctx is done, or whose rate
+// limit wait fails, are passed to skip with the reason instead.
+func runParallel(ctx context.Context, indexes []int, opts *BatchOptions, run func(i int), skip func(i int, err error)) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var limiter *client.RateLimiter
+	if opts.RateLimit > 0 {
+		limiter = client.NewRateLimiter(opts.RateLimit, opts.RateLimitBurst)
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(indexes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if err := ctx.Err(); err != nil {
+					skip(i, err)
+					continue
+				}
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						skip(i, err)
+						continue
+					}
+				}
+				run(i)
+			}
+		}()
+	}
+
+dispatch:
+	for n, i := range indexes {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			// Skip everything not yet handed out so it can be resumed
+			for _, j := range indexes[n:] {
+				skip(j, ctx.Err())
+			}
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+}
@@ -0,0 +1,80 @@
+package link
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rejectLongURLs fails the creation of short codes for the given long URLs
+func rejectLongURLs(longURLs ...string) func(string, string, map[string]interface{}) (*client.Response, error) {
+	return func(method, id string, body map[string]interface{}) (*client.Response, error) {
+		longURL, _ := body["long_url"].(string)
+		if method == http.MethodPost && slices.Contains(longURLs, longURL) {
+			return fakeResponse(http.StatusBadRequest, nil)
+		}
+		return nil, nil
+	}
+}
+
+func TestCreateShortCodes(t *testing.T) {
+	requests := []ShortCodeRequest{
+		{LongURL: "https://example.com/0", Domain: "short.link"},
+		{LongURL: "https://example.com/1", Domain: "short.link"},
+		{LongURL: "https://example.com/2", Domain: "short.link"},
+		{LongURL: "https://example.com/3", Domain: "short.link"},
+	}
+
+	t.Run("returns_results_in_input_order_with_partial_failures", func(t *testing.T) {
+		codes := newFakeCodes()
+		codes.fail = rejectLongURLs("https://example.com/2")
+
+		results, err := codes.link().CreateShortCodes(context.Background(), requests, &BatchOptions{Concurrency: 3})
+
+		require.Len(t, results, 4)
+		for i, result := range results {
+			assert.Equal(t, i, result.Index)
+			assert.Equal(t, requests[i], result.Request)
+		}
+		assert.Equal(t, "https://example.com/0", results[0].ShortCode.LongURL)
+		assert.Nil(t, results[2].ShortCode)
+		assert.EqualError(t, results[2].Err, "failed to create short code: HTTP 400: Bad Request")
+		assert.Len(t, results.Succeeded(), 3)
+		assert.EqualError(t, err, "1 of 4 batch entries failed, first error: failed to create short code: HTTP 400: Bad Request")
+	})
+
+	t.Run("resumes_only_the_failed_entries", func(t *testing.T) {
+		codes := newFakeCodes()
+		codes.fail = rejectLongURLs("https://example.com/1", "https://example.com/3")
+		link := codes.link()
+		first, err := link.CreateShortCodes(context.Background(), requests, nil)
+		require.Error(t, err)
+		codes.fail = nil
+		codes.calls = nil
+
+		resumed, err := link.ResumeShortCodes(context.Background(), first, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"POST", "POST"}, codes.calls)
+		assert.Len(t, resumed.Succeeded(), 4)
+		assert.Equal(t, "https://example.com/3", resumed[3].ShortCode.LongURL)
+		assert.Error(t, first[1].Err, "the previous results are not modified")
+	})
+
+	t.Run("marks_unsent_entries_with_the_context_error", func(t *testing.T) {
+		codes := newFakeCodes()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results, err := codes.link().CreateShortCodes(ctx, requests, &BatchOptions{Concurrency: 1})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Len(t, results.Failed(), 4)
+		assert.Empty(t, codes.calls)
+	})
+}
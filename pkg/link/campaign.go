@@ -0,0 +1,164 @@
+package link
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// UTM query parameter names
+const (
+	UTMSource   = "utm_source"
+	UTMMedium   = "utm_medium"
+	UTMCampaign = "utm_campaign"
+	UTMTerm     = "utm_term"
+	UTMContent  = "utm_content"
+)
+
+// Prefixes of the tags CreateCampaignShortCode adds to short codes
+const (
+	CampaignTagPrefix = "campaign:"
+	SourceTagPrefix   = "source:"
+	MediumTagPrefix   = "medium:"
+)
+
+// Campaign describes the UTM parameters added to a long URL
+type Campaign struct {
+	Source  string            // utm_source, such as newsletter. Required
+	Medium  string            // utm_medium, such as email. Required
+	Name    string            // utm_campaign, such as spring_sale. Required
+	Term    string            // utm_term, usually a paid search keyword
+	Content string            // utm_content, to tell apart links in the same campaign
+	Params  map[string]string // Additional query parameters, added in key order
+}
+
+// Validate checks that the required parameters are set
+func (c Campaign) Validate() error {
+	var v validator
+	for _, field := range []struct{ name, value string }{
+		{UTMSource, c.Source},
+		{UTMMedium, c.Medium},
+		{UTMCampaign, c.Name},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			v.add(field.name, "is required")
+		}
+	}
+	return v.err()
+}
+
+// params returns the query parameters of the campaign in the order they are
+// added to URLs
+func (c Campaign) params() [][2]string {
+	params := [][2]string{
+		{UTMSource, c.Source},
+		{UTMMedium, c.Medium},
+		{UTMCampaign, c.Name},
+	}
+	if c.Term != "" {
+		params = append(params, [2]string{UTMTerm, c.Term})
+	}
+	if c.Content != "" {
+		params = append(params, [2]string{UTMContent, c.Content})
+	}
+	keys := make([]string, 0, len(c.Params))
+	for key := range c.Params {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		params = append(params, [2]string{key, c.Params[key]})
+	}
+	return params
+}
+
+// BuildURL adds the campaign parameters to longURL. Existing query parameters
+// and the fragment are kept in place; parameters the campaign sets replace any
+// existing values of the same name.
+func (c Campaign) BuildURL(longURL string) (string, error) {
+	var v validator
+	v.longURL("long_url", longURL, true)
+	if err := v.err(); err != nil {
+		return "", err
+	}
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return "", err
+	}
+
+	params := c.params()
+	replaced := map[string]bool{}
+	for _, param := range params {
+		replaced[param[0]] = true
+	}
+
+	var query []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil && replaced[name] {
+			continue
+		}
+		query = append(query, pair)
+	}
+	for _, param := range params {
+		query = append(query, url.QueryEscape(param[0])+"="+url.QueryEscape(param[1]))
+	}
+	u.RawQuery = strings.Join(query, "&")
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// Tags returns the tags identifying the campaign, such as campaign:spring_sale
+func (c Campaign) Tags() []string {
+	return []string{
+		CampaignTagPrefix + c.Name,
+		SourceTagPrefix + c.Source,
+		MediumTagPrefix + c.Medium,
+	}
+}
+
+// Title returns the title given to campaign short codes, such as
+// "spring_sale (newsletter / email)"
+func (c Campaign) Title() string {
+	title := c.Name + " (" + c.Source + " / " + c.Medium + ")"
+	if c.Content != "" {
+		title += " - " + c.Content
+	}
+	return title
+}
+
+// CreateCampaignShortCode creates a short code for longURL with the campaign
+// parameters added. The short code is tagged with Campaign.Tags in addition to
+// any tags in opts, and titled with Campaign.Title unless opts sets a title.
+func (l *Link) CreateCampaignShortCode(ctx context.Context, longURL, domain string, campaign Campaign, opts *CreateShortCodeOptions) (*ShortCodeResponse, error) {
+	campaignURL, err := campaign.BuildURL(longURL)
+	if err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+
+	codeOpts := CreateShortCodeOptions{}
+	if opts != nil {
+		codeOpts = *opts
+	}
+	codeOpts.Tags = slices.Clone(codeOpts.Tags)
+	for _, tag := range campaign.Tags() {
+		if !slices.Contains(codeOpts.Tags, tag) {
+			codeOpts.Tags = append(codeOpts.Tags, tag)
+		}
+	}
+	if codeOpts.Title == "" {
+		codeOpts.Title = campaign.Title()
+	}
+
+	return l.CreateShortCode(ctx, campaignURL, domain, &codeOpts)
+}
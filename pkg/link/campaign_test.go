@@ -0,0 +1,99 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignBuildURL(t *testing.T) {
+	campaign := Campaign{Source: "newsletter", Medium: "email", Name: "spring sale"}
+
+	t.Run("adds_utm_parameters", func(t *testing.T) {
+		result, err := campaign.BuildURL("https://example.com/shop")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/shop?utm_source=newsletter&utm_medium=email&utm_campaign=spring+sale", result)
+	})
+
+	t.Run("preserves_existing_query_and_fragment", func(t *testing.T) {
+		full := Campaign{
+			Source:  "newsletter",
+			Medium:  "email",
+			Name:    "spring",
+			Term:    "shoes",
+			Content: "header",
+			Params:  map[string]string{"ref": "a&b", "lang": "en"},
+		}
+
+		result, err := full.BuildURL("https://example.com/shop?b=2&utm_source=old&a=1#top")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/shop?b=2&a=1&utm_source=newsletter&utm_medium=email&utm_campaign=spring&utm_term=shoes&utm_content=header&lang=en&ref=a%26b#top", result)
+	})
+
+	t.Run("requires_source_medium_and_name", func(t *testing.T) {
+		_, err := Campaign{Source: "newsletter"}.BuildURL("https://example.com")
+
+		assert.EqualError(t, err, "invalid request: utm_medium is required; utm_campaign is required")
+	})
+
+	t.Run("rejects_invalid_urls", func(t *testing.T) {
+		_, err := campaign.BuildURL("example.com")
+
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
+
+func TestCreateCampaignShortCode(t *testing.T) {
+	var actualBody map[string]interface{}
+	link := &Link{
+		uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+		organizationID: "theOrgId",
+		client: &FakeHTTPClient{
+			PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+				actualBody = body.(map[string]interface{})
+				responseBody, _ := json.Marshal(&ShortCodeResponse{ID: "theId"})
+				return &client.Response{StatusCode: http.StatusCreated, Body: responseBody}, nil
+			},
+		},
+	}
+	campaign := Campaign{Source: "newsletter", Medium: "email", Name: "spring_sale", Content: "header"}
+
+	t.Run("builds_the_url_tags_and_title", func(t *testing.T) {
+		opts := &CreateShortCodeOptions{Code: "spring", Tags: []string{"promo", "medium:email"}}
+
+		result, err := link.CreateCampaignShortCode(context.Background(), "https://example.com/?a=1", "short.link", campaign, opts)
+
+		require.NoError(t, err)
+		assert.Equal(t, "theId", result.ID)
+		assert.Equal(t, "https://example.com/?a=1&utm_source=newsletter&utm_medium=email&utm_campaign=spring_sale&utm_content=header", actualBody["long_url"])
+		assert.Equal(t, "spring", actualBody["code"])
+		assert.Equal(t, "spring_sale (newsletter / email) - header", actualBody["title"])
+		assert.Equal(t, []string{"promo", "medium:email", "campaign:spring_sale", "source:newsletter"}, actualBody["tags"])
+		assert.Equal(t, []string{"promo", "medium:email"}, opts.Tags, "the caller's options are not modified")
+	})
+
+	t.Run("keeps_an_explicit_title", func(t *testing.T) {
+		_, err := link.CreateCampaignShortCode(context.Background(), "https://example.com", "short.link", campaign, &CreateShortCodeOptions{Title: "Mine"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Mine", actualBody["title"])
+	})
+
+	t.Run("reports_invalid_campaigns", func(t *testing.T) {
+		var handled error
+		link := &Link{errorHandler: func(err error) { handled = err }}
+
+		_, err := link.CreateCampaignShortCode(context.Background(), "https://example.com", "short.link", Campaign{}, nil)
+
+		assert.EqualError(t, err, "invalid request: utm_source is required; utm_medium is required; utm_campaign is required")
+		assert.Equal(t, err, handled)
+	})
+}
@@ -0,0 +1,15 @@
+package link
+
+// DefaultDomain returns the domain used when none is passed to CreateShortCode.
+// It is a setting of this client, not the organization's default domain.
+func (l *Link) DefaultDomain() string {
+	return l.defaultDomain
+}
+
+// domainOrDefault returns domain, or the configured default when it is empty
+func (l *Link) domainOrDefault(domain string) string {
+	if domain == "" {
+		return l.defaultDomain
+	}
+	return domain
+}
@@ -0,0 +1,47 @@
+package link
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultDomain(t *testing.T) {
+	t.Run("creates_short_codes_on_the_default_domain", func(t *testing.T) {
+		var actualBody map[string]interface{}
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			defaultDomain:  "go.example.com",
+			client: &FakeHTTPClient{
+				PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+					actualBody = body.(map[string]interface{})
+					return &client.Response{StatusCode: http.StatusCreated, Body: []byte(`{"id":"theId"}`)}, nil
+				},
+			},
+		}
+
+		_, err := link.CreateShortCode(context.Background(), "https://example.com", "", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "go.example.com", actualBody["domain"])
+	})
+
+	t.Run("is_validated_by_new", func(t *testing.T) {
+		link, err := New(WithDefaultDomain("not a domain"))
+
+		assert.Nil(t, link)
+		assert.EqualError(t, err, "invalid request: default_domain must be a domain name such as h4n.link")
+	})
+
+	t.Run("is_set_by_new", func(t *testing.T) {
+		link, err := New(WithDefaultDomain("go.example.com"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "go.example.com", link.DefaultDomain())
+	})
+}
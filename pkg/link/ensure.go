@@ -0,0 +1,194 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EnsureAction is what EnsureShortCode did to reach the requested state
+type EnsureAction string
+
+const (
+	EnsureCreated   EnsureAction = "created"   // No matching short code existed
+	EnsureUpdated   EnsureAction = "updated"   // A match existed but its title or tags differed
+	EnsureUnchanged EnsureAction = "unchanged" // A match existed as requested
+)
+
+// ErrShortCodeConflict is returned by EnsureShortCode when a short code with
+// the requested custom code exists on the domain for a different long URL
+var ErrShortCodeConflict = errors.New("short code exists for a different long URL")
+
+// EnsureResult reports the outcome of EnsureShortCode
+type EnsureResult struct {
+	ShortCode *ShortCodeResponse
+	Action    EnsureAction
+}
+
+// EnsureShortCode returns the short code for longURL on domain, creating it
+// when absent. It is safe to repeat, so re-running a pipeline does not create
+// duplicates.
+//
+// With a custom code in opts the short code with that code on domain is used,
+// and it is an ErrShortCodeConflict if it points to another long URL. Otherwise
+// the first short code with the same long URL and domain carrying every tag in
+// opts is used. A match whose title or tags differ from opts is updated; tags
+// only ever change when opts has some or an expiry, and an existing expiry tag
+// is kept unless opts sets another.
+//
+// Each call lists short codes to find the match. The listing can only be
+// narrowed by tags, so without a custom code it covers the codes carrying the
+// tags in opts, but with a custom code it pages through every short code of
+// the organization, costing one request per page. For many calls over a
+// large organization, list once with ShortCodes and create the missing codes
+// instead.
+//
+// Calls for the same code, or the same long URL, domain and tags, are
+// serialized within a Link so concurrent callers create one short code.
+func (l *Link) EnsureShortCode(ctx context.Context, longURL, domain string, opts *CreateShortCodeOptions) (*EnsureResult, error) {
+	domain = l.domainOrDefault(domain)
+	if err := ValidateShortCode(longURL, domain, opts); err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+	if opts == nil {
+		opts = &CreateShortCodeOptions{}
+	}
+
+	unlock := l.ensureLocks.lock(ensureKey(longURL, domain, opts))
+	defer unlock()
+
+	existing, err := l.findShortCode(ctx, longURL, domain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		created, err := l.CreateShortCode(ctx, longURL, domain, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &EnsureResult{ShortCode: created, Action: EnsureCreated}, nil
+	}
+
+	update := &UpdateShortCodeOptions{}
+	if opts.Title != "" && opts.Title != existing.Title {
+		update.Title = opts.Title
+	}
+	if len(opts.Tags) > 0 || !opts.ExpiresAt.IsZero() {
+		tags := existing.Tags
+		if len(opts.Tags) > 0 {
+			tags = keepExpiry(opts.Tags, existing.Tags)
+		}
+		if !opts.ExpiresAt.IsZero() {
+			tags = withExpiry(tags, opts.ExpiresAt)
+		}
+		if !sameTags(tags, existing.Tags) {
+			update.Tags = tags
+		}
+	}
+	if update.Title == "" && update.Tags == nil {
+		return &EnsureResult{ShortCode: existing, Action: EnsureUnchanged}, nil
+	}
+
+	updated, err := l.UpdateShortCode(ctx, existing.ID, update)
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureResult{ShortCode: updated, Action: EnsureUpdated}, nil
+}
+
+// ensureKey identifies the short code an EnsureShortCode call is after
+func ensureKey(longURL, domain string, opts *CreateShortCodeOptions) string {
+	if opts.Code != "" {
+		return "code\x00" + opts.Code
+	}
+	tags := slices.Clone(opts.Tags)
+	slices.Sort(tags)
+	return "url\x00" + domain + "\x00" + longURL + "\x00" + strings.Join(tags, "\x00")
+}
+
+// findShortCode looks up the short code EnsureShortCode should reuse, returning
+// nil when there is none. Custom codes are matched by listing every short
+// code, since the short code routes take IDs rather than codes and the
+// listing has no code filter; filtering by tags would miss a code whose tags
+// drifted.
+func (l *Link) findShortCode(ctx context.Context, longURL, domain string, opts *CreateShortCodeOptions) (*ShortCodeResponse, error) {
+	listOpts := &ShortCodesOptions{Tags: opts.Tags}
+	if opts.Code != "" {
+		listOpts = nil
+	}
+
+	for code, err := range l.ShortCodes(ctx, listOpts) {
+		if err != nil {
+			return nil, err
+		}
+		if code.Domain != domain {
+			continue
+		}
+		if opts.Code == "" {
+			if code.LongURL == longURL {
+				return &code, nil
+			}
+			continue
+		}
+		if code.Code != opts.Code {
+			continue
+		}
+		if code.LongURL != longURL {
+			err := fmt.Errorf("%w: %s points to %s on %s", ErrShortCodeConflict, opts.Code, code.LongURL, code.Domain)
+			l.emitError(err)
+			return nil, err
+		}
+		return &code, nil
+	}
+	return nil, nil
+}
+
+// sameTags reports whether two tag lists hold the same tags in any order
+func sameTags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// keyedLocks hands out one mutex per key, dropping it once unused
+type keyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock locks the mutex for key and returns the function unlocking it
+func (k *keyedLocks) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = map[string]*keyedLock{}
+	}
+	lock := k.locks[key]
+	if lock == nil {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.refs++
+	k.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		k.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
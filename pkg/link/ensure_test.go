@@ -0,0 +1,117 @@
+package link
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureShortCode(t *testing.T) {
+	// The same code on another domain is never a match
+	other := ShortCodeResponse{ID: "other", Code: "theCode", LongURL: "https://example.com", Domain: "other.link"}
+	existing := &ShortCodeResponse{
+		ID:      "theId",
+		Code:    "theCode",
+		LongURL: "https://example.com",
+		Domain:  "short.link",
+		Title:   "theTitle",
+		Tags:    []string{"a", "b"},
+	}
+
+	t.Run("creates_a_code_missing_from_the_domain", func(t *testing.T) {
+		codes := newFakeCodes(other)
+
+		result, err := codes.link().EnsureShortCode(context.Background(), "https://example.com", "short.link", &CreateShortCodeOptions{Code: "theCode"})
+
+		require.NoError(t, err)
+		assert.Equal(t, EnsureCreated, result.Action)
+		assert.Equal(t, "newId1", result.ShortCode.ID)
+		assert.Equal(t, []string{"GET ?pageNum=1", "POST"}, codes.calls)
+	})
+
+	t.Run("reuses_a_code_found_by_long_url_and_tags", func(t *testing.T) {
+		codes := newFakeCodes(other, *existing)
+
+		result, err := codes.link().EnsureShortCode(context.Background(), "https://example.com", "short.link", &CreateShortCodeOptions{
+			Title: "theTitle",
+			Tags:  []string{"b", "a"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, EnsureUnchanged, result.Action)
+		assert.Equal(t, existing, result.ShortCode)
+		assert.Equal(t, []string{"GET ?pageNum=1&tags=b%2Ca"}, codes.calls)
+	})
+
+	t.Run("updates_a_drifted_title_and_tags", func(t *testing.T) {
+		codes := newFakeCodes(other, *existing)
+
+		result, err := codes.link().EnsureShortCode(context.Background(), "https://example.com", "short.link", &CreateShortCodeOptions{
+			Code:  "theCode",
+			Title: "newTitle",
+			Tags:  []string{"a", "b", "c"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, EnsureUpdated, result.Action)
+		assert.Equal(t, "newTitle", result.ShortCode.Title)
+		assert.Equal(t, []string{"a", "b", "c"}, result.ShortCode.Tags)
+		assert.Equal(t, []string{"GET ?pageNum=1", "PATCH /theId"}, codes.calls)
+	})
+
+	t.Run("keeps_the_expiry_tag_when_updating_tags", func(t *testing.T) {
+		expiring := *existing
+		expiring.Tags = []string{"a", "expires:2025-06-30T00:00:00Z"}
+		codes := newFakeCodes(other, expiring)
+
+		result, err := codes.link().EnsureShortCode(context.Background(), "https://example.com", "short.link", &CreateShortCodeOptions{
+			Code:  "theCode",
+			Title: "theTitle",
+			Tags:  []string{"a", "b"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, EnsureUpdated, result.Action)
+		assert.Equal(t, []string{"a", "b", "expires:2025-06-30T00:00:00Z"}, result.ShortCode.Tags)
+	})
+
+	t.Run("reports_a_code_used_for_another_url", func(t *testing.T) {
+		var handled error
+		codes := newFakeCodes(other, *existing)
+		link := codes.link()
+		link.errorHandler = func(err error) { handled = err }
+
+		_, err := link.EnsureShortCode(context.Background(), "https://elsewhere.com", "short.link", &CreateShortCodeOptions{Code: "theCode"})
+
+		assert.ErrorIs(t, err, ErrShortCodeConflict)
+		assert.Equal(t, err, handled)
+		assert.Equal(t, []string{"GET ?pageNum=1"}, codes.calls)
+	})
+}
+
+func TestKeyedLocks(t *testing.T) {
+	var locks keyedLocks
+
+	unlockA := locks.lock("a")
+	unlockB := locks.lock("b") // A different key does not block
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock := locks.lock("a")
+		close(acquired)
+		unlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("the same key was locked twice")
+	default:
+	}
+	unlockA()
+	<-acquired
+	unlockB()
+
+	assert.Empty(t, locks.locks)
+}
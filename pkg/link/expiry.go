@@ -0,0 +1,205 @@
+package link
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The Link API has no expiry of its own, so intended expiries are kept in a
+// tag such as expires:2025-06-30T23:59:59Z and enforced by SweepExpired.
+const (
+	ExpiryTagPrefix  = "expires:" // Marks when a short code should stop working
+	ExpiredTagPrefix = "expired:" // Replaces the expiry tag of a retargeted short code
+)
+
+// ExpiryTag returns the tag recording an expiry at t, at second precision in UTC
+func ExpiryTag(t time.Time) string {
+	return ExpiryTagPrefix + t.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// ExpiresAt returns when the short code is meant to expire, from its expiry tag
+func (s ShortCodeResponse) ExpiresAt() (time.Time, bool) {
+	for _, tag := range s.Tags {
+		if value, ok := strings.CutPrefix(tag, ExpiryTagPrefix); ok {
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// withExpiry replaces any expiry tag in tags with one for expiresAt, or just
+// removes it when expiresAt is zero
+func withExpiry(tags []string, expiresAt time.Time) []string {
+	tags = slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+		return strings.HasPrefix(tag, ExpiryTagPrefix)
+	})
+	if !expiresAt.IsZero() {
+		tags = append(tags, ExpiryTag(expiresAt))
+	}
+	return tags
+}
+
+// keepExpiry returns tags with the expiry tag of current, so replacing the
+// tags of a short code does not drop its expiry
+func keepExpiry(tags, current []string) []string {
+	expiresAt, ok := ShortCodeResponse{Tags: current}.ExpiresAt()
+	if !ok {
+		return tags
+	}
+	return withExpiry(tags, expiresAt)
+}
+
+// createTags returns the tags sent when creating a short code
+func (o *CreateShortCodeOptions) createTags() []string {
+	if o.ExpiresAt.IsZero() {
+		return o.Tags
+	}
+	return withExpiry(o.Tags, o.ExpiresAt)
+}
+
+// SetExpiry records when a short code should expire by replacing its expiry
+// tag. A zero expiresAt removes the expiry. To change other fields at the same
+// time, set ExpiresAt in UpdateShortCodeOptions instead.
+func (l *Link) SetExpiry(ctx context.Context, code string, expiresAt time.Time) (*ShortCodeResponse, error) {
+	shortCode, err := l.GetShortCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return l.patchShortCode(ctx, code, map[string]interface{}{
+		"tags": append([]string{}, withExpiry(shortCode.Tags, expiresAt)...),
+	})
+}
+
+// Actions taken by SweepExpired
+const (
+	SweepDeleted    = "deleted"
+	SweepRetargeted = "retargeted"
+)
+
+// SweepOptions configures SweepExpired
+type SweepOptions struct {
+	// FallbackURL retargets expired short codes to this page instead of
+	// deleting them. Their expiry tag is replaced with an expired: tag.
+	FallbackURL string
+	Now         time.Time     // The time to compare expiries with, defaults to now
+	DryRun      bool          // Report the expired codes without changing them
+	Batch       *BatchOptions // Concurrency and rate limit for the changes
+}
+
+// SweepResult is the handling of one expired short code
+type SweepResult struct {
+	ShortCode ShortCodeResponse // The short code as it was found
+	ExpiredAt time.Time         // Its recorded expiry
+	Action    string            // SweepDeleted or SweepRetargeted
+	Err       error             // Why the action failed
+}
+
+// SweepReport describes the outcome of SweepExpired
+type SweepReport struct {
+	DryRun  bool
+	Expired []SweepResult // One entry per expired short code, in listing order
+}
+
+// Err summarizes the failed actions, or returns nil if every action succeeded
+func (r *SweepReport) Err() error {
+	var failed []SweepResult
+	for _, result := range r.Expired {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: len(failed), Total: len(r.Expired), First: failed[0].Err}
+}
+
+// SweepExpired finds every short code whose expiry tag has passed and deletes
+// it, or retargets it to the fallback URL. The error is a *BatchError when
+// some actions failed.
+func (l *Link) SweepExpired(ctx context.Context, opts *SweepOptions) (*SweepReport, error) {
+	if opts == nil {
+		opts = &SweepOptions{}
+	}
+	if opts.FallbackURL != "" {
+		var v validator
+		v.longURL("fallback_url", opts.FallbackURL, true)
+		if err := v.err(); err != nil {
+			l.emitError(err)
+			return nil, err
+		}
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	action := SweepDeleted
+	if opts.FallbackURL != "" {
+		action = SweepRetargeted
+	}
+
+	report := &SweepReport{DryRun: opts.DryRun}
+	for code, err := range l.ShortCodes(ctx, nil) {
+		if err != nil {
+			return nil, err
+		}
+		if expiresAt, ok := code.ExpiresAt(); ok && !expiresAt.After(now) {
+			report.Expired = append(report.Expired, SweepResult{ShortCode: code, ExpiredAt: expiresAt, Action: action})
+		}
+	}
+
+	if opts.DryRun || len(report.Expired) == 0 {
+		return report, nil
+	}
+
+	indexes := make([]int, len(report.Expired))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	runParallel(ctx, indexes, opts.Batch, func(i int) {
+		result := &report.Expired[i]
+		code := result.ShortCode
+		if result.Action == SweepDeleted {
+			result.Err = l.DeleteShortCode(ctx, code.ID)
+			return
+		}
+		tags := append(withExpiry(code.Tags, time.Time{}), ExpiredTagPrefix+result.ExpiredAt.UTC().Format(time.RFC3339))
+		_, result.Err = l.patchShortCode(ctx, code.ID, map[string]interface{}{
+			"long_url": opts.FallbackURL,
+			"tags":     tags,
+		})
+	}, func(i int, err error) {
+		report.Expired[i].Err = err
+	})
+
+	return report, report.Err()
+}
+
+// SweepExpiredEvery runs SweepExpired right away and then at every interval
+// until ctx is done, passing each outcome to handle, which may be nil. With
+// an interval of zero or less it sweeps once and returns.
+func (l *Link) SweepExpiredEvery(ctx context.Context, interval time.Duration, opts *SweepOptions, handle func(*SweepReport, error)) {
+	if handle == nil {
+		handle = func(*SweepReport, error) {}
+	}
+	if interval <= 0 {
+		handle(l.SweepExpired(ctx, opts))
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		handle(l.SweepExpired(ctx, opts))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package link
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryTags(t *testing.T) {
+	expiresAt := time.Date(2025, 6, 30, 23, 59, 59, 500, time.FixedZone("CEST", 2*60*60))
+
+	assert.Equal(t, "expires:2025-06-30T21:59:59Z", ExpiryTag(expiresAt))
+
+	got, ok := ShortCodeResponse{Tags: []string{"promo", "expires:2025-06-30T21:59:59Z"}}.ExpiresAt()
+	assert.True(t, ok)
+	assert.True(t, got.Equal(expiresAt.Truncate(time.Second)))
+
+	_, ok = ShortCodeResponse{Tags: []string{"expires:soon"}}.ExpiresAt()
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"promo", "expires:2025-06-30T21:59:59Z"}, withExpiry([]string{"expires:2024-01-01T00:00:00Z", "promo"}, expiresAt))
+}
+
+func TestCreateShortCodeWithExpiry(t *testing.T) {
+	var actualBody map[string]interface{}
+	link := &Link{
+		uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+		organizationID: "theOrgId",
+		client: &FakeHTTPClient{
+			PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+				actualBody = body.(map[string]interface{})
+				return &client.Response{StatusCode: http.StatusCreated, Body: []byte(`{"id":"theId"}`)}, nil
+			},
+		},
+	}
+
+	_, err := link.CreateShortCode(context.Background(), "https://example.com", "short.link", &CreateShortCodeOptions{
+		Tags:      []string{"promo"},
+		ExpiresAt: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"promo", "expires:2025-06-30T00:00:00Z"}, actualBody["tags"])
+}
+
+func TestSetExpiry(t *testing.T) {
+	codes := newFakeCodes(ShortCodeResponse{ID: "one", Tags: []string{"expires:2025-01-01T00:00:00Z"}})
+	link := codes.link()
+
+	_, err := link.SetExpiry(context.Background(), "one", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expires:2025-02-01T00:00:00Z"}, codes.updates["one"].Tags)
+
+	_, err = link.SetExpiry(context.Background(), "one", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, codes.updates["one"].Tags)
+}
+
+func TestUpdateShortCodeWithExpiry(t *testing.T) {
+	expiresAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	expiring := ShortCodeResponse{ID: "one", Tags: []string{"promo", "expires:2025-01-01T00:00:00Z"}}
+
+	t.Run("keeps_the_other_tags", func(t *testing.T) {
+		codes := newFakeCodes(expiring)
+
+		_, err := codes.link().UpdateShortCode(context.Background(), "one", &UpdateShortCodeOptions{Title: "theTitle", ExpiresAt: expiresAt})
+
+		require.NoError(t, err)
+		assert.Equal(t, UpdateShortCodeOptions{
+			Title: "theTitle",
+			Tags:  []string{"promo", "expires:2025-02-01T00:00:00Z"},
+		}, codes.updates["one"])
+	})
+
+	t.Run("adds_the_expiry_to_new_tags", func(t *testing.T) {
+		codes := newFakeCodes(expiring)
+
+		_, err := codes.link().UpdateShortCode(context.Background(), "one", &UpdateShortCodeOptions{Tags: []string{"summer"}, ExpiresAt: expiresAt})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"summer", "expires:2025-02-01T00:00:00Z"}, codes.updates["one"].Tags)
+	})
+}
+
+func TestSweepExpired(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	expiring := []ShortCodeResponse{
+		{ID: "past", Code: "a", Tags: []string{"promo", "expires:2025-06-30T00:00:00Z"}},
+		{ID: "future", Code: "b", Tags: []string{"expires:2025-08-01T00:00:00Z"}},
+		{ID: "none", Code: "c", Tags: []string{"promo"}},
+		{ID: "exact", Code: "d", Tags: []string{"expires:2025-07-01T12:00:00Z"}},
+	}
+
+	t.Run("deletes_expired_codes", func(t *testing.T) {
+		codes := newFakeCodes(expiring...)
+
+		report, err := codes.link().SweepExpired(context.Background(), &SweepOptions{Now: now})
+
+		require.NoError(t, err)
+		require.Len(t, report.Expired, 2)
+		assert.Equal(t, SweepDeleted, report.Expired[0].Action)
+		assert.Equal(t, time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), report.Expired[0].ExpiredAt)
+		assert.ElementsMatch(t, []string{"past", "exact"}, codes.deleted)
+		assert.Empty(t, codes.updates)
+	})
+
+	t.Run("retargets_expired_codes_to_a_fallback", func(t *testing.T) {
+		codes := newFakeCodes(expiring...)
+
+		report, err := codes.link().SweepExpired(context.Background(), &SweepOptions{
+			Now:         now,
+			FallbackURL: "https://example.com/promo-ended",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, SweepRetargeted, report.Expired[0].Action)
+		assert.Empty(t, codes.deleted)
+		assert.Equal(t, UpdateShortCodeOptions{
+			LongURL: "https://example.com/promo-ended",
+			Tags:    []string{"promo", "expired:2025-06-30T00:00:00Z"},
+		}, codes.updates["past"])
+		assert.Len(t, codes.updates, 2)
+	})
+
+	t.Run("changes_nothing_in_a_dry_run", func(t *testing.T) {
+		codes := newFakeCodes(expiring...)
+
+		report, err := codes.link().SweepExpired(context.Background(), &SweepOptions{Now: now, DryRun: true})
+
+		require.NoError(t, err)
+		assert.Len(t, report.Expired, 2)
+		assert.Empty(t, codes.deleted)
+		assert.Empty(t, codes.updates)
+	})
+
+	t.Run("rejects_an_invalid_fallback", func(t *testing.T) {
+		_, err := newFakeCodes().link().SweepExpired(context.Background(), &SweepOptions{FallbackURL: "/promo-ended"})
+
+		assert.EqualError(t, err, "invalid request: fallback_url must be an absolute http or https URL")
+	})
+
+	t.Run("sweeps_until_cancelled", func(t *testing.T) {
+		link := newFakeCodes(expiring...).link()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		runs := 0
+		link.SweepExpiredEvery(ctx, time.Millisecond, &SweepOptions{Now: now, DryRun: true}, func(report *SweepReport, err error) {
+			require.NoError(t, err)
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+		})
+
+		assert.Equal(t, 3, runs)
+	})
+
+	t.Run("sweeps_once_without_an_interval", func(t *testing.T) {
+		link := newFakeCodes(expiring...).link()
+
+		runs := 0
+		link.SweepExpiredEvery(context.Background(), 0, &SweepOptions{Now: now, DryRun: true}, func(report *SweepReport, err error) {
+			require.NoError(t, err)
+			runs++
+		})
+
+		assert.Equal(t, 1, runs)
+	})
+}
@@ -0,0 +1,299 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+)
+
+const (
+	// defaultFailoverCooldown is how long a failing URI is skipped after its
+	// first failure
+	defaultFailoverCooldown = 30 * time.Second
+	// maxFailoverBackoff caps the doubling of the cooldown for repeated failures
+	maxFailoverBackoff = 5 * time.Minute
+)
+
+// placeholderPattern matches template placeholders such as {region}
+var placeholderPattern = regexp.MustCompile(`\{[A-Za-z0-9_]+\}`)
+
+// target is a request URI built from one configured base URI
+type target struct {
+	base string
+	uri  string
+}
+
+// uriState is the health of one configured base URI
+type uriState struct {
+	failures  int
+	lastErr   error
+	failedAt  time.Time
+	skipUntil time.Time
+}
+
+// URIHealth reports the health of a configured Link URI
+type URIHealth struct {
+	URI                 string    // The configured URI template
+	Healthy             bool      // False while the URI is cooling down after failures
+	ConsecutiveFailures int       // Failures since the last success
+	LastError           error     // The most recent failure, nil once the URI succeeds again
+	LastFailure         time.Time // When the most recent failure happened
+	RetryAt             time.Time // When the URI is preferred again, zero when healthy
+}
+
+// Health returns the health of every configured URI in configuration order
+func (l *Link) Health() []URIHealth {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	health := make([]URIHealth, 0, len(l.uris))
+	for _, base := range l.uris {
+		h := URIHealth{URI: base, Healthy: true}
+		if state := l.uriStates[base]; state != nil {
+			h.ConsecutiveFailures = state.failures
+			h.LastError = state.lastErr
+			h.LastFailure = state.failedAt
+			if now.Before(state.skipUntil) {
+				h.Healthy = false
+				h.RetryAt = state.skipUntil
+			}
+		}
+		health = append(health, h)
+	}
+	return health
+}
+
+// targets builds the request URI for every configured base URI, healthy ones
+// first in configuration order, then cooling-down ones by how soon they recover
+func (l *Link) targets(prefix1, prefix2, prefix3 string) ([]target, error) {
+	if l.organizationID == "" {
+		return nil, fmt.Errorf("organization ID is required")
+	}
+
+	targets := make([]target, 0, len(l.uris))
+	for _, base := range l.uris {
+		uri, err := l.expandURI(base)
+		if err != nil {
+			return nil, err
+		}
+		for _, segment := range []string{prefix1, prefix2, prefix3} {
+			if segment != "" {
+				uri = strings.TrimSuffix(uri, "/") + "/" + segment
+			}
+		}
+		targets = append(targets, target{base: base, uri: strings.TrimSuffix(uri, "/")})
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	skipUntil := func(t target) time.Time {
+		if state := l.uriStates[t.base]; state != nil && now.Before(state.skipUntil) {
+			return state.skipUntil
+		}
+		return time.Time{}
+	}
+	slices.SortStableFunc(targets, func(a, b target) int {
+		return skipUntil(a).Compare(skipUntil(b))
+	})
+	l.mu.Unlock()
+
+	return targets, nil
+}
+
+// expandURI fills in the {organizationId} placeholder and any configured URI
+// variables, failing on placeholders left unresolved
+func (l *Link) expandURI(base string) (string, error) {
+	var missing string
+	uri := placeholderPattern.ReplaceAllStringFunc(base, func(placeholder string) string {
+		name := strings.Trim(placeholder, "{}")
+		if name == "organizationId" {
+			return l.organizationID
+		}
+		if value, ok := l.uriVariables[name]; ok {
+			return value
+		}
+		if missing == "" {
+			missing = placeholder
+		}
+		return placeholder
+	})
+	if missing != "" {
+		return "", fmt.Errorf("no value for %s in link URI %s", missing, base)
+	}
+	return uri, nil
+}
+
+// withQuery appends encoded query parameters to every target
+func withQuery(targets []target, params url.Values) []target {
+	if len(params) == 0 {
+		return targets
+	}
+	query := params.Encode()
+	for i := range targets {
+		targets[i].uri += "?" + query
+	}
+	return targets
+}
+
+// do sends a buffered request, failing over across targets
+func (l *Link) do(ctx context.Context, targets []target, method string, body interface{}, headers map[string]string) (*client.Response, error) {
+	return failover(ctx, l, targets, method, func(uri string) (*client.Response, int, error) {
+		var resp *client.Response
+		var err error
+		switch method {
+		case http.MethodGet:
+			resp, err = l.client.Get(ctx, uri, headers)
+		case http.MethodPost:
+			resp, err = l.client.Post(ctx, uri, body, headers)
+		case http.MethodPut:
+			resp, err = l.client.Put(ctx, uri, body, headers)
+		case http.MethodPatch:
+			resp, err = l.client.Patch(ctx, uri, body, headers)
+		case http.MethodDelete:
+			resp, err = l.client.Delete(ctx, uri, headers)
+		default:
+			return nil, 0, fmt.Errorf("unsupported method %s", method)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp, resp.StatusCode, nil
+	}, func(*client.Response) {})
+}
+
+// stream sends a request with a streamed response body, failing over across
+// targets. The caller must close the returned body.
+func (l *Link) stream(ctx context.Context, targets []target, method string, body interface{}, headers map[string]string) (*client.StreamResponse, error) {
+	return failover(ctx, l, targets, method, func(uri string) (*client.StreamResponse, int, error) {
+		resp, err := client.Stream(ctx, l.client, method, uri, body, headers)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp, resp.StatusCode, nil
+	}, func(resp *client.StreamResponse) {
+		resp.Body.Close()
+	})
+}
+
+// failover calls each target in turn. It moves on after a transport error or
+// a server error while other targets remain; any other response is returned.
+// The response of the last target is returned even when it is a server error
+// so callers report the status as usual.
+//
+// Requests that are not idempotent, such as creating a short code, may have
+// been applied by a server that then failed, so they only move on when the
+// request was never sent.
+func failover[T any](ctx context.Context, l *Link, targets []target, method string, call func(uri string) (T, int, error), discard func(T)) (T, error) {
+	idempotent := isIdempotent(method)
+
+	var zero T
+	var lastErr error
+	for i, t := range targets {
+		last := i == len(targets)-1
+
+		resp, status, err := call(t.uri)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The caller gave up; that says nothing about the URI
+				return zero, err
+			}
+			retry := idempotent || notSent(err)
+			l.markFailure(ctx, t, err, last || !retry)
+			lastErr = err
+			if !retry {
+				return zero, err
+			}
+			continue
+		}
+
+		if status >= http.StatusInternalServerError {
+			l.markFailure(ctx, t, fmt.Errorf("HTTP %d", status), last || !idempotent)
+			if !last && idempotent {
+				discard(resp)
+				continue
+			}
+			return resp, nil
+		}
+
+		l.markSuccess(t)
+		return resp, nil
+	}
+
+	if len(targets) > 1 {
+		lastErr = fmt.Errorf("all %d link URIs failed, last error: %w", len(targets), lastErr)
+	}
+	return zero, lastErr
+}
+
+// isIdempotent reports whether repeating a request with method has the same
+// effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// notSent reports whether err shows a request never reached the server,
+// because its host did not resolve or the connection could not be made
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// markFailure records a failure and skips the URI for a cooldown that doubles
+// with each consecutive failure. Final is set when no other URI is tried.
+func (l *Link) markFailure(ctx context.Context, t target, err error, final bool) {
+	l.mu.Lock()
+	if l.uriStates == nil {
+		l.uriStates = map[string]*uriState{}
+	}
+	state := l.uriStates[t.base]
+	if state == nil {
+		state = &uriState{}
+		l.uriStates[t.base] = state
+	}
+
+	cooldown := l.failoverCooldown
+	if cooldown <= 0 {
+		cooldown = defaultFailoverCooldown
+	}
+	for range min(state.failures, 10) {
+		cooldown *= 2
+	}
+	state.failures++
+	state.lastErr = err
+	state.failedAt = time.Now()
+	state.skipUntil = state.failedAt.Add(min(cooldown, max(maxFailoverBackoff, l.failoverCooldown)))
+	l.mu.Unlock()
+
+	if !final {
+		l.log().WarnContext(ctx, "link URI failed",
+			slog.String(client.LogKeyURL, client.RedactURL(t.uri)),
+			slog.Any(client.LogKeyError, err),
+		)
+	}
+}
+
+// markSuccess clears the failure history of a URI
+func (l *Link) markSuccess(t target) {
+	l.mu.Lock()
+	delete(l.uriStates, t.base)
+	l.mu.Unlock()
+}
@@ -0,0 +1,252 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hostClient answers GET requests with the status configured for each host
+// and records the hosts it was called for
+func hostClient(statuses map[string]int, calls *[]string) *FakeHTTPClient {
+	return &FakeHTTPClient{
+		GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+			host := strings.Split(strings.TrimPrefix(url, "https://"), "/")[0]
+			*calls = append(*calls, host)
+			status, ok := statuses[host]
+			if !ok {
+				return nil, errors.New("connection refused")
+			}
+			return &client.Response{StatusCode: status, Status: http.StatusText(status), Body: []byte(`{"id":"theId","code":"theCode"}`)}, nil
+		},
+	}
+}
+
+func TestTargetTemplates(t *testing.T) {
+	t.Run("fills_in_uri_variables", func(t *testing.T) {
+		link := &Link{
+			uris:           []string{"https://{region}.api.test.com/{organizationId}/codes/"},
+			uriVariables:   map[string]string{"region": "eu"},
+			organizationID: "theOrgId",
+		}
+
+		targets, err := link.targets("theCode", "", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://eu.api.test.com/theOrgId/codes/theCode", targets[0].uri)
+	})
+
+	t.Run("rejects_unresolved_placeholders", func(t *testing.T) {
+		link := &Link{
+			uris:           []string{"https://{region}.api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+		}
+
+		_, err := link.targets("", "", "")
+
+		assert.EqualError(t, err, "no value for {region} in link URI https://{region}.api.test.com/{organizationId}/codes/")
+	})
+}
+
+func TestFailover(t *testing.T) {
+	uris := []string{
+		"https://primary.test.com/{organizationId}/codes/",
+		"https://secondary.test.com/{organizationId}/codes/",
+	}
+
+	t.Run("fails_over_on_network_errors_and_prefers_healthy_uris", func(t *testing.T) {
+		var calls []string
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client:         hostClient(map[string]int{"secondary.test.com": http.StatusOK}, &calls),
+		}
+
+		code, err := link.GetShortCode(context.Background(), "theCode")
+		require.NoError(t, err)
+		assert.Equal(t, "theId", code.ID)
+		assert.Equal(t, []string{"primary.test.com", "secondary.test.com"}, calls)
+
+		health := link.Health()
+		assert.False(t, health[0].Healthy)
+		assert.Equal(t, 1, health[0].ConsecutiveFailures)
+		assert.EqualError(t, health[0].LastError, "connection refused")
+		assert.WithinDuration(t, time.Now().Add(defaultFailoverCooldown), health[0].RetryAt, time.Second)
+		assert.True(t, health[1].Healthy)
+
+		// The cooling-down primary is tried last
+		calls = nil
+		_, err = link.GetShortCode(context.Background(), "theCode")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"secondary.test.com"}, calls)
+	})
+
+	t.Run("fails_over_on_server_errors_but_not_client_errors", func(t *testing.T) {
+		var calls []string
+		statuses := map[string]int{"primary.test.com": http.StatusServiceUnavailable, "secondary.test.com": http.StatusNotFound}
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client:         hostClient(statuses, &calls),
+		}
+
+		_, err := link.GetShortCode(context.Background(), "theCode")
+
+		assert.EqualError(t, err, "failed to get short code: HTTP 404: Not Found")
+		assert.Equal(t, []string{"primary.test.com", "secondary.test.com"}, calls)
+		assert.True(t, link.Health()[1].Healthy, "a 404 is not the server's fault")
+	})
+
+	t.Run("returns_the_last_server_error", func(t *testing.T) {
+		var calls []string
+		statuses := map[string]int{"primary.test.com": http.StatusBadGateway, "secondary.test.com": http.StatusServiceUnavailable}
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client:         hostClient(statuses, &calls),
+		}
+
+		_, err := link.GetShortCode(context.Background(), "theCode")
+
+		assert.EqualError(t, err, "failed to get short code: HTTP 503: Service Unavailable")
+	})
+
+	t.Run("reports_when_every_uri_fails", func(t *testing.T) {
+		var calls []string
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client:         hostClient(nil, &calls),
+		}
+
+		_, err := link.GetShortCode(context.Background(), "theCode")
+
+		assert.EqualError(t, err, "failed to get short code: all 2 link URIs failed, last error: connection refused")
+	})
+
+	t.Run("does_not_replay_a_post_after_a_server_error", func(t *testing.T) {
+		var calls []string
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+					calls = append(calls, strings.Split(strings.TrimPrefix(url, "https://"), "/")[0])
+					return &client.Response{StatusCode: http.StatusBadGateway, Status: http.StatusText(http.StatusBadGateway)}, nil
+				},
+			},
+		}
+
+		_, err := link.CreateShortCode(context.Background(), "https://example.com", "short.link", nil)
+
+		assert.EqualError(t, err, "failed to create short code: HTTP 502: Bad Gateway")
+		assert.Equal(t, []string{"primary.test.com"}, calls)
+		assert.False(t, link.Health()[0].Healthy)
+	})
+
+	t.Run("fails_over_a_post_that_was_never_sent", func(t *testing.T) {
+		var calls []string
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+					host := strings.Split(strings.TrimPrefix(url, "https://"), "/")[0]
+					calls = append(calls, host)
+					if host == "primary.test.com" {
+						return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+					}
+					return &client.Response{StatusCode: http.StatusCreated, Body: []byte(`{"id":"theId"}`)}, nil
+				},
+			},
+		}
+
+		code, err := link.CreateShortCode(context.Background(), "https://example.com", "short.link", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "theId", code.ID)
+		assert.Equal(t, []string{"primary.test.com", "secondary.test.com"}, calls)
+	})
+
+	t.Run("does_not_replay_a_post_that_may_have_been_sent", func(t *testing.T) {
+		var calls []string
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+					calls = append(calls, strings.Split(strings.TrimPrefix(url, "https://"), "/")[0])
+					return nil, &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+				},
+			},
+		}
+
+		_, err := link.CreateShortCode(context.Background(), "https://example.com", "short.link", nil)
+
+		assert.ErrorContains(t, err, "connection reset by peer")
+		assert.Equal(t, []string{"primary.test.com"}, calls)
+	})
+
+	t.Run("fails_over_streamed_requests", func(t *testing.T) {
+		var calls []string
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client:         hostClient(map[string]int{"primary.test.com": http.StatusInternalServerError, "secondary.test.com": http.StatusOK}, &calls),
+		}
+
+		_, err := link.GetShortCodes(context.Background(), "", nil, 1, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"primary.test.com", "secondary.test.com"}, calls)
+	})
+
+	t.Run("doubles_the_cooldown_and_resets_on_success", func(t *testing.T) {
+		var calls []string
+		statuses := map[string]int{}
+		link := &Link{
+			uris:             uris[:1],
+			organizationID:   "theOrgId",
+			failoverCooldown: time.Minute,
+			client:           hostClient(statuses, &calls),
+		}
+
+		link.GetShortCode(context.Background(), "theCode")
+		link.GetShortCode(context.Background(), "theCode")
+		health := link.Health()[0]
+		assert.Equal(t, 2, health.ConsecutiveFailures)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), health.RetryAt, time.Second)
+
+		statuses["primary.test.com"] = http.StatusOK
+		_, err := link.GetShortCode(context.Background(), "theCode")
+		require.NoError(t, err)
+		assert.Equal(t, URIHealth{URI: uris[0], Healthy: true}, link.Health()[0])
+	})
+
+	t.Run("does_not_blame_uris_for_cancelled_requests", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		link := &Link{
+			uris:           uris,
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+					cancel()
+					return nil, ctx.Err()
+				},
+			},
+		}
+
+		_, err := link.GetShortCode(ctx, "theCode")
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.True(t, link.Health()[0].Healthy)
+	})
+}
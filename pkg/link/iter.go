@@ -0,0 +1,140 @@
+package link
+
+import (
+	"context"
+	"iter"
+	"strings"
+)
+
+// ShortCodesOptions configures iteration over short codes
+type ShortCodesOptions struct {
+	TitleSearch string   // Only return codes whose title matches
+	Tags        []string // Only return codes carrying all of these tags
+	PageSize    int      // Page size requested from the server, zero for the server default
+	Prefetch    bool     // Fetch the next page while the current one is consumed
+}
+
+// QRCodesOptions configures iteration over the QR codes of a short code
+type QRCodesOptions struct {
+	TitleSearch string // Only return QR codes whose title contains this text, case-insensitively
+	PageSize    int    // Page size requested from the server, zero for the server default
+	Prefetch    bool   // Fetch the next page while the current one is consumed
+}
+
+// ShortCodes returns an iterator over every short code of the organization,
+// fetching pages transparently. Iteration stops after the first error, which
+// is yielded with a zero ShortCodeResponse. Breaking out of the loop stops
+// paging.
+//
+//	for code, err := range l.ShortCodes(ctx, &link.ShortCodesOptions{Tags: []string{"campaign"}}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(code.Code)
+//	}
+func (l *Link) ShortCodes(ctx context.Context, opts *ShortCodesOptions) iter.Seq2[ShortCodeResponse, error] {
+	if opts == nil {
+		opts = &ShortCodesOptions{}
+	}
+
+	fetch := func(ctx context.Context, pageNum int) ([]ShortCodeResponse, int, error) {
+		response, err := l.getShortCodes(ctx, opts.TitleSearch, opts.Tags, pageNum, opts.PageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return response.Data, response.Total, nil
+	}
+
+	return paginate(ctx, l, opts.Prefetch, fetch, nil)
+}
+
+// QRCodes returns an iterator over every QR code of a short code, fetching
+// pages transparently. It behaves like ShortCodes.
+func (l *Link) QRCodes(ctx context.Context, code string, opts *QRCodesOptions) iter.Seq2[QRCodeResponse, error] {
+	if opts == nil {
+		opts = &QRCodesOptions{}
+	}
+
+	fetch := func(ctx context.Context, pageNum int) ([]QRCodeResponse, int, error) {
+		response, err := l.getQRCodes(ctx, code, pageNum, opts.PageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return response.Data, response.Total, nil
+	}
+
+	var filter func(QRCodeResponse) bool
+	if opts.TitleSearch != "" {
+		search := strings.ToLower(opts.TitleSearch)
+		filter = func(qr QRCodeResponse) bool {
+			return strings.Contains(strings.ToLower(qr.Title), search)
+		}
+	}
+
+	return paginate(ctx, l, opts.Prefetch, fetch, filter)
+}
+
+// pageResult is a fetched page handed from a prefetch goroutine
+type pageResult[T any] struct {
+	items []T
+	total int
+	err   error
+}
+
+// paginate walks 1-based pages returned by fetch until the reported total is
+// reached or a page comes back empty. Errors are reported through the Link
+// error handler and yielded once. A nil filter keeps every item.
+func paginate[T any](ctx context.Context, l *Link, prefetch bool, fetch func(context.Context, int) ([]T, int, error), filter func(T) bool) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		// Cancelling on return stops an in-flight prefetch after early termination
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		fetchAsync := func(pageNum int) <-chan pageResult[T] {
+			result := make(chan pageResult[T], 1)
+			go func() {
+				items, total, err := fetch(ctx, pageNum)
+				result <- pageResult[T]{items: items, total: total, err: err}
+			}()
+			return result
+		}
+
+		var pending <-chan pageResult[T]
+		seen := 0
+		for pageNum := 1; ; pageNum++ {
+			var page pageResult[T]
+			if pending != nil {
+				page = <-pending
+				pending = nil
+			} else {
+				page.items, page.total, page.err = fetch(ctx, pageNum)
+			}
+
+			if page.err != nil {
+				l.emitError(page.err)
+				var zero T
+				yield(zero, page.err)
+				return
+			}
+
+			seen += len(page.items)
+			more := len(page.items) > 0 && seen < page.total
+			if more && prefetch {
+				pending = fetchAsync(pageNum + 1)
+			}
+
+			for _, item := range page.items {
+				if filter != nil && !filter(item) {
+					continue
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if !more {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedShortCodesClient serves total short codes in pages of pageSize
+func pagedShortCodesClient(total, pageSize int, requested *[]int, mu *sync.Mutex) *FakeHTTPClient {
+	return &FakeHTTPClient{
+		GetFake: func(ctx context.Context, rawURL string, headers map[string]string) (*client.Response, error) {
+			u, _ := url.Parse(rawURL)
+			pageNum, _ := strconv.Atoi(u.Query().Get("pageNum"))
+			mu.Lock()
+			*requested = append(*requested, pageNum)
+			mu.Unlock()
+
+			response := GetShortCodesResponse{Total: total, PageNum: pageNum, PageSize: pageSize}
+			for i := (pageNum - 1) * pageSize; i < min(pageNum*pageSize, total); i++ {
+				response.Data = append(response.Data, ShortCodeResponse{Code: fmt.Sprintf("code%d", i)})
+			}
+			body, _ := json.Marshal(response)
+			return &client.Response{StatusCode: http.StatusOK, Body: body}, nil
+		},
+	}
+}
+
+func TestShortCodes(t *testing.T) {
+	t.Run("iterates_over_every_page", func(t *testing.T) {
+		var requested []int
+		var mu sync.Mutex
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			client:         pagedShortCodesClient(5, 2, &requested, &mu),
+		}
+
+		var codes []string
+		for code, err := range link.ShortCodes(context.Background(), &ShortCodesOptions{PageSize: 2}) {
+			require.NoError(t, err)
+			codes = append(codes, code.Code)
+		}
+
+		assert.Equal(t, []string{"code0", "code1", "code2", "code3", "code4"}, codes)
+		assert.Equal(t, []int{1, 2, 3}, requested)
+	})
+
+	t.Run("prefetches_pages_and_stops_early", func(t *testing.T) {
+		var requested []int
+		var mu sync.Mutex
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			client:         pagedShortCodesClient(10, 2, &requested, &mu),
+		}
+
+		var codes []string
+		for code, err := range link.ShortCodes(context.Background(), &ShortCodesOptions{PageSize: 2, Prefetch: true}) {
+			require.NoError(t, err)
+			codes = append(codes, code.Code)
+			if len(codes) == 3 {
+				break
+			}
+		}
+
+		assert.Equal(t, []string{"code0", "code1", "code2"}, codes)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.LessOrEqual(t, len(requested), 3)
+	})
+
+	t.Run("passes_title_and_tag_filters_to_the_server", func(t *testing.T) {
+		var actualURL string
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+					actualURL = url
+					return &client.Response{StatusCode: http.StatusOK, Body: []byte(`{"total":0,"data":[]}`)}, nil
+				},
+			},
+		}
+
+		for range link.ShortCodes(context.Background(), &ShortCodesOptions{TitleSearch: "theTitle", Tags: []string{"tag1", "tag2"}}) {
+		}
+
+		assert.Equal(t, "https://api.test.com/theOrgId/codes?pageNum=1&tags=tag1%2Ctag2&title=theTitle", actualURL)
+	})
+
+	t.Run("yields_the_error_and_stops", func(t *testing.T) {
+		var handledError error
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			errorHandler:   func(err error) { handledError = err },
+			client: &FakeHTTPClient{
+				GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+					return &client.Response{StatusCode: http.StatusInternalServerError, Status: "Internal Server Error"}, nil
+				},
+			},
+		}
+
+		var errs []error
+		for _, err := range link.ShortCodes(context.Background(), nil) {
+			errs = append(errs, err)
+		}
+
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "failed to get short codes: HTTP 500: Internal Server Error")
+		assert.Equal(t, errs[0], handledError)
+	})
+}
+
+func TestQRCodes(t *testing.T) {
+	t.Run("filters_qr_codes_by_title", func(t *testing.T) {
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+					body, _ := json.Marshal(GetQRCodesResponse{Total: 3, Data: []QRCodeResponse{
+						{ID: "1", Title: "Poster"},
+						{ID: "2", Title: "Flyer"},
+						{ID: "3", Title: "Big poster"},
+					}})
+					return &client.Response{StatusCode: http.StatusOK, Body: body}, nil
+				},
+			},
+		}
+
+		var ids []string
+		for qr, err := range link.QRCodes(context.Background(), "theCode", &QRCodesOptions{TitleSearch: "poster"}) {
+			require.NoError(t, err)
+			ids = append(ids, qr.ID)
+		}
+
+		assert.Equal(t, []string{"1", "3"}, ids)
+	})
+}
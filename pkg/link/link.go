@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Hyphen/go-sdk/internal/client"
@@ -33,6 +35,10 @@ type CreateShortCodeOptions struct {
 	Code  string   `json:"code,omitempty"`
 	Title string   `json:"title,omitempty"`
 	Tags  []string `json:"tags,omitempty"`
+
+	// ExpiresAt records when the short code should expire as an expiry tag,
+	// enforced by SweepExpired
+	ExpiresAt time.Time `json:"-"`
 }
 
 // ShortCodeResponse represents a short code response
@@ -52,6 +58,10 @@ type UpdateShortCodeOptions struct {
 	LongURL string   `json:"long_url,omitempty"`
 	Title   string   `json:"title,omitempty"`
 	Tags    []string `json:"tags,omitempty"`
+
+	// ExpiresAt replaces the expiry tag of the short code, keeping its other
+	// tags unless Tags is set. A zero time leaves the tags as they are.
+	ExpiresAt time.Time `json:"-"`
 }
 
 // GetShortCodesResponse represents a paginated response of short codes
@@ -78,6 +88,9 @@ type QRCodeResponse struct {
 	QRCode      string `json:"qrCode"`
 	QRCodeBytes []byte `json:"-"`
 	QRLink      string `json:"qrLink"`
+
+	// QRCodeMIMEType is the type of the image in QRCodeBytes, such as image/png
+	QRCodeMIMEType string `json:"-"`
 }
 
 // GetQRCodesResponse represents a paginated response of QR codes
@@ -90,9 +103,9 @@ type GetQRCodesResponse struct {
 
 // ClicksByDay represents daily click statistics
 type ClicksByDay struct {
-	Date   string `json:"date"`
-	Total  int    `json:"total"`
-	Unique int    `json:"unique"`
+	Date   time.Time `json:"date"`
+	Total  int       `json:"total"`
+	Unique int       `json:"unique"`
 }
 
 // ClicksStats represents click statistics
@@ -102,20 +115,59 @@ type ClicksStats struct {
 	ByDay  []ClicksByDay `json:"byDay"`
 }
 
+// ReferralStats represents clicks coming from a referring URL
+type ReferralStats struct {
+	URL   string         `json:"url"`
+	Total int            `json:"total"`
+	Raw   map[string]any `json:"-"` // Every field of the entry as received
+}
+
+// BrowserStats represents clicks from a browser
+type BrowserStats struct {
+	Name  string         `json:"name"`
+	Total int            `json:"total"`
+	Raw   map[string]any `json:"-"` // Every field of the entry as received
+}
+
+// DeviceStats represents clicks from a device type
+type DeviceStats struct {
+	Name  string         `json:"name"`
+	Total int            `json:"total"`
+	Raw   map[string]any `json:"-"` // Every field of the entry as received
+}
+
+// LocationStats represents clicks from a location
+type LocationStats struct {
+	Country string         `json:"country"`
+	City    string         `json:"city,omitempty"`
+	Total   int            `json:"total"`
+	Unique  int            `json:"unique,omitempty"`
+	Raw     map[string]any `json:"-"` // Every field of the entry as received
+}
+
 // GetCodeStatsResponse represents code statistics response
 type GetCodeStatsResponse struct {
-	Clicks    ClicksStats `json:"clicks"`
-	Referrals []any       `json:"referrals"`
-	Browsers  []any       `json:"browsers"`
-	Devices   []any       `json:"devices"`
-	Locations []any       `json:"locations"`
+	Clicks    ClicksStats     `json:"clicks"`
+	Referrals []ReferralStats `json:"referrals"`
+	Browsers  []BrowserStats  `json:"browsers"`
+	Devices   []DeviceStats   `json:"devices"`
+	Locations []LocationStats `json:"locations"`
 }
 
 // Options represents configuration options for the Link client
 type Options struct {
-	URIs           []string
-	OrganizationID string
-	APIKey         string
+	URIs             []string
+	URIVariables     map[string]string
+	FailoverCooldown time.Duration
+	OrganizationID   string
+	APIKey           string
+	DefaultDomain    string
+	Logger           *slog.Logger
+	RateLimit        float64
+	RateLimitBurst   int
+	MaxInFlight      int
+	MaxResponseSize  int64
+	HTTPClient       *http.Client
 }
 
 // Option is a functional option for configuring the Link client
@@ -135,20 +187,95 @@ func WithOrganizationID(id string) Option {
 	}
 }
 
-// WithURIs sets the base URIs for the Link service
+// WithDefaultDomain sets the domain used when CreateShortCode and the other
+// create operations are given an empty domain
+func WithDefaultDomain(domain string) Option {
+	return func(o *Options) {
+		o.DefaultDomain = domain
+	}
+}
+
+// WithURIs sets the base URIs for the Link service. Requests go to the first
+// healthy URI and fail over to the next on network errors and 5xx responses.
 func WithURIs(uris []string) Option {
 	return func(o *Options) {
 		o.URIs = uris
 	}
 }
 
+// WithURIVariables sets values for placeholders in the URIs, such as {region}
+// in "https://{region}.api.example.com/{organizationId}/codes/".
+// {organizationId} is always filled in from the organization ID.
+func WithURIVariables(vars map[string]string) Option {
+	return func(o *Options) {
+		o.URIVariables = vars
+	}
+}
+
+// WithFailoverCooldown sets how long a URI is skipped after a failure. The
+// cooldown doubles with each consecutive failure. Defaults to 30 seconds.
+func WithFailoverCooldown(d time.Duration) Option {
+	return func(o *Options) {
+		o.FailoverCooldown = d
+	}
+}
+
+// WithLogger sets the structured logger used for request and error events
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithRateLimit limits requests to rps per second with bursts of up to burst
+// requests. Calls block until capacity is available or the context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *Options) {
+		o.RateLimit = rps
+		o.RateLimitBurst = burst
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent requests
+func WithMaxInFlight(n int) Option {
+	return func(o *Options) {
+		o.MaxInFlight = n
+	}
+}
+
+// WithMaxResponseSize limits the decompressed size of response bodies. Zero
+// keeps the default limit of 32 MiB and a negative value disables the guard.
+func WithMaxResponseSize(n int64) Option {
+	return func(o *Options) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithHTTPClient sets the *http.Client used to send requests, for example to
+// install a custom transport
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = httpClient
+	}
+}
+
 // Link is the client for URL shortening services
 type Link struct {
-	uris           []string
-	organizationID string
-	apiKey         string
-	client         client.HTTPClient
-	errorHandler   func(error)
+	uris             []string
+	uriVariables     map[string]string
+	failoverCooldown time.Duration
+	organizationID   string
+	apiKey           string
+	defaultDomain    string
+	client           client.HTTPClient
+	errorHandler     func(error)
+	logger           *slog.Logger
+	resolver         *http.Client
+
+	mu        sync.Mutex
+	uriStates map[string]*uriState
+
+	ensureLocks keyedLocks
 }
 
 var defaultLinkURIs = []string{
@@ -178,17 +305,38 @@ func New(options ...Option) (*Link, error) {
 		organizationID = os.Getenv("HYPHEN_ORGANIZATION_ID")
 	}
 
+	if opts.DefaultDomain != "" {
+		var v validator
+		v.domain("default_domain", opts.DefaultDomain)
+		if err := v.err(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Set URIs
 	uris := opts.URIs
 	if len(uris) == 0 {
 		uris = defaultLinkURIs
 	}
 
+	logger := client.ServiceLogger(opts.Logger, "link")
+
 	l := &Link{
-		uris:           uris,
-		organizationID: organizationID,
-		apiKey:         apiKey,
-		client:         client.NewClient(""),
+		uris:             uris,
+		uriVariables:     opts.URIVariables,
+		failoverCooldown: opts.FailoverCooldown,
+		organizationID:   organizationID,
+		apiKey:           apiKey,
+		defaultDomain:    opts.DefaultDomain,
+		client: client.NewClient("",
+			client.WithLogger(logger),
+			client.WithRateLimit(opts.RateLimit, opts.RateLimitBurst),
+			client.WithMaxInFlight(opts.MaxInFlight),
+			client.WithMaxResponseSize(opts.MaxResponseSize),
+			client.WithHTTPClient(opts.HTTPClient),
+		),
+		logger:   logger,
+		resolver: newResolver(opts.HTTPClient),
 	}
 
 	return l, nil
@@ -199,51 +347,32 @@ func (l *Link) SetErrorHandler(handler func(error)) {
 	l.errorHandler = handler
 }
 
-// emitError calls the error handler if set
+// emitError logs the error and calls the error handler if set
 func (l *Link) emitError(err error) {
+	l.log().Error("operation failed", slog.Any(client.LogKeyError, err))
 	if l.errorHandler != nil {
 		l.errorHandler(err)
 	}
 }
 
-// getURI constructs the URI for a specific request
-func (l *Link) getURI(prefix1, prefix2, prefix3 string) (string, error) {
-	if l.organizationID == "" {
-		return "", fmt.Errorf("organization ID is required")
-	}
-
-	uri := strings.Replace(l.uris[0], "{organizationId}", l.organizationID, 1)
-
-	if prefix1 != "" {
-		if strings.HasSuffix(uri, "/") {
-			uri = uri + prefix1 + "/"
-		} else {
-			uri = uri + "/" + prefix1
-		}
-	}
-
-	if prefix2 != "" {
-		if strings.HasSuffix(uri, "/") {
-			uri = uri + prefix2 + "/"
-		} else {
-			uri = uri + "/" + prefix2
-		}
-	}
-
-	if prefix3 != "" {
-		if strings.HasSuffix(uri, "/") {
-			uri = uri + prefix3 + "/"
-		} else {
-			uri = uri + "/" + prefix3
-		}
+// log returns the configured logger, or a discarding logger when none is set
+func (l *Link) log() *slog.Logger {
+	if l.logger == nil {
+		return client.DiscardLogger()
 	}
-
-	return strings.TrimSuffix(uri, "/"), nil
+	return l.logger
 }
 
-// CreateShortCode creates a short code for a long URL
+// CreateShortCode creates a short code for a long URL. An empty domain uses
+// the default domain set with WithDefaultDomain.
 func (l *Link) CreateShortCode(ctx context.Context, longURL, domain string, opts *CreateShortCodeOptions) (*ShortCodeResponse, error) {
-	uri, err := l.getURI("", "", "")
+	domain = l.domainOrDefault(domain)
+	if err := ValidateShortCode(longURL, domain, opts); err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+
+	targets, err := l.targets("", "", "")
 	if err != nil {
 		l.emitError(err)
 		return nil, err
@@ -261,13 +390,13 @@ func (l *Link) CreateShortCode(ctx context.Context, longURL, domain string, opts
 		if opts.Title != "" {
 			body["title"] = opts.Title
 		}
-		if len(opts.Tags) > 0 {
-			body["tags"] = opts.Tags
+		if tags := opts.createTags(); len(tags) > 0 {
+			body["tags"] = tags
 		}
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Post(ctx, uri, body, headers)
+	resp, err := l.do(ctx, targets, http.MethodPost, body, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to create short code: %w", err)
 		l.emitError(err)
@@ -292,14 +421,14 @@ func (l *Link) CreateShortCode(ctx context.Context, longURL, domain string, opts
 
 // GetShortCode retrieves a short code by its code
 func (l *Link) GetShortCode(ctx context.Context, code string) (*ShortCodeResponse, error) {
-	uri, err := l.getURI(code, "", "")
+	targets, err := l.targets(code, "", "")
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Get(ctx, uri, headers)
+	resp, err := l.do(ctx, targets, http.MethodGet, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to get short code: %w", err)
 		l.emitError(err)
@@ -324,11 +453,20 @@ func (l *Link) GetShortCode(ctx context.Context, code string) (*ShortCodeRespons
 
 // GetShortCodes retrieves all short codes for the organization
 func (l *Link) GetShortCodes(ctx context.Context, titleSearch string, tags []string, pageNumber, pageSize int) (*GetShortCodesResponse, error) {
-	uri, err := l.getURI("", "", "")
+	response, err := l.getShortCodes(ctx, titleSearch, tags, pageNumber, pageSize)
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
+	return response, nil
+}
+
+// getShortCodes fetches one page of short codes without reporting errors
+func (l *Link) getShortCodes(ctx context.Context, titleSearch string, tags []string, pageNumber, pageSize int) (*GetShortCodesResponse, error) {
+	targets, err := l.targets("", "", "")
+	if err != nil {
+		return nil, err
+	}
 
 	// Build query parameters
 	params := url.Values{}
@@ -345,28 +483,24 @@ func (l *Link) GetShortCodes(ctx context.Context, titleSearch string, tags []str
 		params.Add("pageSize", fmt.Sprintf("%d", pageSize))
 	}
 
-	if len(params) > 0 {
-		uri = uri + "?" + params.Encode()
-	}
+	targets = withQuery(targets, params)
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Get(ctx, uri, headers)
+	resp, err := l.stream(ctx, targets, http.MethodGet, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to get short codes: %w", err)
-		l.emitError(err)
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		err = fmt.Errorf("failed to get short codes: HTTP %d: %s", resp.StatusCode, resp.Status)
-		l.emitError(err)
 		return nil, err
 	}
 
 	var response GetShortCodesResponse
-	if err := json.Unmarshal(resp.Body, &response); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		err = fmt.Errorf("failed to unmarshal response: %w", err)
-		l.emitError(err)
 		return nil, err
 	}
 
@@ -375,14 +509,14 @@ func (l *Link) GetShortCodes(ctx context.Context, titleSearch string, tags []str
 
 // GetTags retrieves all tags associated with the organization's short codes
 func (l *Link) GetTags(ctx context.Context) ([]string, error) {
-	uri, err := l.getURI("tags", "", "")
+	targets, err := l.targets("tags", "", "")
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Get(ctx, uri, headers)
+	resp, err := l.do(ctx, targets, http.MethodGet, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to get tags: %w", err)
 		l.emitError(err)
@@ -407,7 +541,7 @@ func (l *Link) GetTags(ctx context.Context) ([]string, error) {
 
 // GetCodeStats retrieves statistics for a specific short code
 func (l *Link) GetCodeStats(ctx context.Context, code string, startDate, endDate time.Time) (*GetCodeStatsResponse, error) {
-	uri, err := l.getURI(code, "stats", "")
+	targets, err := l.targets(code, "stats", "")
 	if err != nil {
 		l.emitError(err)
 		return nil, err
@@ -417,10 +551,10 @@ func (l *Link) GetCodeStats(ctx context.Context, code string, startDate, endDate
 	params := url.Values{}
 	params.Add("startDate", startDate.Format(time.RFC3339))
 	params.Add("endDate", endDate.Format(time.RFC3339))
-	uri = uri + "?" + params.Encode()
+	targets = withQuery(targets, params)
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Get(ctx, uri, headers)
+	resp, err := l.do(ctx, targets, http.MethodGet, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to get code stats: %w", err)
 		l.emitError(err)
@@ -445,14 +579,33 @@ func (l *Link) GetCodeStats(ctx context.Context, code string, startDate, endDate
 
 // UpdateShortCode updates a short code
 func (l *Link) UpdateShortCode(ctx context.Context, code string, opts *UpdateShortCodeOptions) (*ShortCodeResponse, error) {
-	uri, err := l.getURI(code, "", "")
+	if err := ValidateShortCodeUpdate(opts); err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+
+	if opts != nil && !opts.ExpiresAt.IsZero() {
+		tags := opts.Tags
+		if tags == nil {
+			current, err := l.GetShortCode(ctx, code)
+			if err != nil {
+				return nil, err
+			}
+			tags = current.Tags
+		}
+		withTags := *opts
+		withTags.Tags = withExpiry(tags, opts.ExpiresAt)
+		opts = &withTags
+	}
+
+	targets, err := l.targets(code, "", "")
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Patch(ctx, uri, opts, headers)
+	resp, err := l.do(ctx, targets, http.MethodPatch, opts, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to update short code: %w", err)
 		l.emitError(err)
@@ -477,14 +630,14 @@ func (l *Link) UpdateShortCode(ctx context.Context, code string, opts *UpdateSho
 
 // DeleteShortCode deletes a short code
 func (l *Link) DeleteShortCode(ctx context.Context, code string) error {
-	uri, err := l.getURI(code, "", "")
+	targets, err := l.targets(code, "", "")
 	if err != nil {
 		l.emitError(err)
 		return err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Delete(ctx, uri, headers)
+	resp, err := l.do(ctx, targets, http.MethodDelete, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to delete short code: %w", err)
 		l.emitError(err)
@@ -502,14 +655,19 @@ func (l *Link) DeleteShortCode(ctx context.Context, code string) error {
 
 // CreateQRCode creates a QR code for a specific short code
 func (l *Link) CreateQRCode(ctx context.Context, code string, opts *CreateQRCodeOptions) (*QRCodeResponse, error) {
-	uri, err := l.getURI(code, "qrs", "")
+	if err := ValidateQRCodeOptions(opts); err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+
+	targets, err := l.targets(code, "qrs", "")
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Post(ctx, uri, opts, headers)
+	resp, err := l.do(ctx, targets, http.MethodPost, opts, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to create QR code: %w", err)
 		l.emitError(err)
@@ -528,20 +686,21 @@ func (l *Link) CreateQRCode(ctx context.Context, code string, opts *CreateQRCode
 		l.emitError(err)
 		return nil, err
 	}
+	l.decodeQRImage(&qrCode)
 
 	return &qrCode, nil
 }
 
 // GetQRCode retrieves a QR code by its ID
 func (l *Link) GetQRCode(ctx context.Context, code, qrID string) (*QRCodeResponse, error) {
-	uri, err := l.getURI(code, "qrs", qrID)
+	targets, err := l.targets(code, "qrs", qrID)
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Get(ctx, uri, headers)
+	resp, err := l.do(ctx, targets, http.MethodGet, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to get QR code: %w", err)
 		l.emitError(err)
@@ -560,17 +719,27 @@ func (l *Link) GetQRCode(ctx context.Context, code, qrID string) (*QRCodeRespons
 		l.emitError(err)
 		return nil, err
 	}
+	l.decodeQRImage(&qrCode)
 
 	return &qrCode, nil
 }
 
 // GetQRCodes retrieves all QR codes for a short code
 func (l *Link) GetQRCodes(ctx context.Context, code string, pageNumber, pageSize int) (*GetQRCodesResponse, error) {
-	uri, err := l.getURI(code, "qrs", "")
+	response, err := l.getQRCodes(ctx, code, pageNumber, pageSize)
 	if err != nil {
 		l.emitError(err)
 		return nil, err
 	}
+	return response, nil
+}
+
+// getQRCodes fetches one page of QR codes without reporting errors
+func (l *Link) getQRCodes(ctx context.Context, code string, pageNumber, pageSize int) (*GetQRCodesResponse, error) {
+	targets, err := l.targets(code, "qrs", "")
+	if err != nil {
+		return nil, err
+	}
 
 	// Build query parameters
 	params := url.Values{}
@@ -581,44 +750,43 @@ func (l *Link) GetQRCodes(ctx context.Context, code string, pageNumber, pageSize
 		params.Add("pageSize", fmt.Sprintf("%d", pageSize))
 	}
 
-	if len(params) > 0 {
-		uri = uri + "?" + params.Encode()
-	}
+	targets = withQuery(targets, params)
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Get(ctx, uri, headers)
+	resp, err := l.stream(ctx, targets, http.MethodGet, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to get QR codes: %w", err)
-		l.emitError(err)
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		err = fmt.Errorf("failed to get QR codes: HTTP %d: %s", resp.StatusCode, resp.Status)
-		l.emitError(err)
 		return nil, err
 	}
 
 	var response GetQRCodesResponse
-	if err := json.Unmarshal(resp.Body, &response); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		err = fmt.Errorf("failed to unmarshal response: %w", err)
-		l.emitError(err)
 		return nil, err
 	}
+	for i := range response.Data {
+		l.decodeQRImage(&response.Data[i])
+	}
 
 	return &response, nil
 }
 
 // DeleteQRCode deletes a QR code by its ID
 func (l *Link) DeleteQRCode(ctx context.Context, code, qrID string) error {
-	uri, err := l.getURI(code, "qrs", qrID)
+	targets, err := l.targets(code, "qrs", qrID)
 	if err != nil {
 		l.emitError(err)
 		return err
 	}
 
 	headers := client.CreateHeaders(l.apiKey)
-	resp, err := l.client.Delete(ctx, uri, headers)
+	resp, err := l.do(ctx, targets, http.MethodDelete, nil, headers)
 	if err != nil {
 		err = fmt.Errorf("failed to delete QR code: %w", err)
 		l.emitError(err)
@@ -1,10 +1,17 @@
 package link
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -56,6 +63,163 @@ func (f *FakeHTTPClient) Delete(ctx context.Context, url string, headers map[str
 	panic("Delete fake not implemented")
 }
 
+// fakeCodes is an in-memory Link API for tests of operations built on listing
+// and changing short codes. It lists codes filtered by tags, gets, creates,
+// updates and deletes them by ID and serves statistics, recording each request
+// as a call such as "GET ?pageNum=1" or "PATCH /theId". Requests are served one
+// at a time, so the hooks need no locking.
+type fakeCodes struct {
+	mu      sync.Mutex
+	codes   []ShortCodeResponse
+	created int
+	calls   []string
+	updates map[string]UpdateShortCodeOptions // The last update sent for each ID
+	deleted []string
+
+	// fail answers a request instead of the store when it returns a response
+	// or an error; id is empty when listing and creating
+	fail func(method, id string, body map[string]interface{}) (*client.Response, error)
+	// stats answers statistics requests, which get empty statistics when nil
+	stats func(id string, start time.Time) (*GetCodeStatsResponse, error)
+}
+
+func newFakeCodes(codes ...ShortCodeResponse) *fakeCodes {
+	return &fakeCodes{codes: slices.Clone(codes), updates: map[string]UpdateShortCodeOptions{}}
+}
+
+// link returns a Link served by the fake
+func (f *fakeCodes) link() *Link {
+	return &Link{
+		uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+		organizationID: "theOrgId",
+		client: &FakeHTTPClient{
+			GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+				return f.serve(http.MethodGet, url, nil)
+			},
+			PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+				return f.serve(http.MethodPost, url, body)
+			},
+			PatchFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+				return f.serve(http.MethodPatch, url, body)
+			},
+			DeleteFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+				return f.serve(http.MethodDelete, url, nil)
+			},
+		},
+	}
+}
+
+func (f *fakeCodes) serve(method, rawURL string, body interface{}) (*client.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Trim(strings.TrimPrefix(u.Path, "/theOrgId/codes"), "/")
+	call := method
+	if path != "" {
+		call += " /" + path
+	} else if u.RawQuery != "" {
+		call += " "
+	}
+	if u.RawQuery != "" {
+		call += "?" + u.RawQuery
+	}
+	f.calls = append(f.calls, call)
+
+	var fields map[string]interface{}
+	data, _ := json.Marshal(body)
+	json.Unmarshal(data, &fields)
+
+	id, sub, _ := strings.Cut(path, "/")
+	if f.fail != nil {
+		if resp, err := f.fail(method, id, fields); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	i := slices.IndexFunc(f.codes, func(code ShortCodeResponse) bool { return code.ID == id })
+	switch {
+	case method == http.MethodGet && id == "":
+		return f.list(u.Query())
+	case method == http.MethodGet && sub == "stats":
+		if f.stats == nil {
+			return fakeResponse(http.StatusOK, GetCodeStatsResponse{})
+		}
+		start, _ := time.Parse(time.RFC3339, u.Query().Get("startDate"))
+		stats, err := f.stats(id, start)
+		if err != nil {
+			return nil, err
+		}
+		return fakeResponse(http.StatusOK, stats)
+	case method == http.MethodPost && id == "":
+		var code ShortCodeResponse
+		json.Unmarshal(data, &code)
+		f.created++
+		code.ID = fmt.Sprintf("newId%d", f.created)
+		if code.Code == "" {
+			code.Code = fmt.Sprintf("newCode%d", f.created)
+		}
+		f.codes = append(f.codes, code)
+		return fakeResponse(http.StatusCreated, code)
+	case i < 0:
+		return fakeResponse(http.StatusNotFound, nil)
+	case method == http.MethodGet:
+		return fakeResponse(http.StatusOK, f.codes[i])
+	case method == http.MethodPatch:
+		var update UpdateShortCodeOptions
+		json.Unmarshal(data, &update)
+		f.updates[id] = update
+		if update.LongURL != "" {
+			f.codes[i].LongURL = update.LongURL
+		}
+		if update.Title != "" {
+			f.codes[i].Title = update.Title
+		}
+		if _, ok := fields["tags"]; ok {
+			f.codes[i].Tags = update.Tags
+		}
+		return fakeResponse(http.StatusOK, f.codes[i])
+	case method == http.MethodDelete:
+		f.deleted = append(f.deleted, id)
+		f.codes = slices.Delete(f.codes, i, i+1)
+		return fakeResponse(http.StatusNoContent, nil)
+	}
+	return fakeResponse(http.StatusMethodNotAllowed, nil)
+}
+
+// list serves the first page of the codes carrying every requested tag
+func (f *fakeCodes) list(query neturl.Values) (*client.Response, error) {
+	var wanted []string
+	if tags := query.Get("tags"); tags != "" {
+		wanted = strings.Split(tags, ",")
+	}
+
+	var page GetShortCodesResponse
+	for _, code := range f.codes {
+		missing := slices.ContainsFunc(wanted, func(tag string) bool { return !slices.Contains(code.Tags, tag) })
+		if !missing {
+			page.Data = append(page.Data, code)
+		}
+	}
+	page.Total = len(page.Data)
+	if pageNum := query.Get("pageNum"); pageNum != "" && pageNum != "1" {
+		page.Data = nil
+	}
+	return fakeResponse(http.StatusOK, page)
+}
+
+// fakeResponse answers with v as JSON, or with no body when v is nil
+func fakeResponse(status int, v interface{}) (*client.Response, error) {
+	resp := &client.Response{StatusCode: status, Status: http.StatusText(status)}
+	if v != nil {
+		resp.Body, _ = json.Marshal(v)
+	}
+	return resp, nil
+}
+
 func TestNew(t *testing.T) {
 	t.Run("creates_a_new_link_client_with_provided_options", func(t *testing.T) {
 		link, err := New(
@@ -123,16 +287,41 @@ func TestSetErrorHandler(t *testing.T) {
 	})
 }
 
-func TestGetURI(t *testing.T) {
+func TestLogging(t *testing.T) {
+	t.Run("logs_errors_with_the_service_attribute", func(t *testing.T) {
+		var logs bytes.Buffer
+		link, _ := New(
+			WithAPIKey("theApiKey"),
+			WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+		)
+		link.organizationID = ""
+
+		_, err := link.GetTags(context.Background())
+
+		assert.Error(t, err)
+		assert.Contains(t, logs.String(), `"level":"ERROR"`)
+		assert.Contains(t, logs.String(), `"service":"link"`)
+		assert.Contains(t, logs.String(), `"error":"organization ID is required"`)
+		assert.NotContains(t, logs.String(), "theApiKey")
+	})
+
+	t.Run("does_not_panic_without_a_logger", func(t *testing.T) {
+		link := &Link{uris: []string{"https://api.test.com/{organizationId}/codes/"}}
+
+		assert.NotPanics(t, func() { link.emitError(assert.AnError) })
+	})
+}
+
+func TestTargets(t *testing.T) {
 	t.Run("returns_an_error_when_organization_id_is_empty", func(t *testing.T) {
 		link := &Link{
 			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
 			organizationID: "",
 		}
 
-		uri, err := link.getURI("", "", "")
+		targets, err := link.targets("", "", "")
 
-		assert.Empty(t, uri)
+		assert.Empty(t, targets)
 		assert.EqualError(t, err, "organization ID is required")
 	})
 
@@ -142,10 +331,10 @@ func TestGetURI(t *testing.T) {
 			organizationID: "theOrgId",
 		}
 
-		uri, err := link.getURI("", "", "")
+		targets, err := link.targets("", "", "")
 
 		assert.NoError(t, err)
-		assert.Equal(t, "https://api.test.com/theOrgId/codes", uri)
+		assert.Equal(t, []target{{base: "https://api.test.com/{organizationId}/codes/", uri: "https://api.test.com/theOrgId/codes"}}, targets)
 	})
 
 	t.Run("constructs_uri_with_prefix1", func(t *testing.T) {
@@ -154,10 +343,10 @@ func TestGetURI(t *testing.T) {
 			organizationID: "theOrgId",
 		}
 
-		uri, err := link.getURI("theCode", "", "")
+		targets, err := link.targets("theCode", "", "")
 
 		assert.NoError(t, err)
-		assert.Equal(t, "https://api.test.com/theOrgId/codes/theCode", uri)
+		assert.Equal(t, []target{{base: "https://api.test.com/{organizationId}/codes/", uri: "https://api.test.com/theOrgId/codes/theCode"}}, targets)
 	})
 
 	t.Run("constructs_uri_with_prefix1_and_prefix2", func(t *testing.T) {
@@ -166,10 +355,10 @@ func TestGetURI(t *testing.T) {
 			organizationID: "theOrgId",
 		}
 
-		uri, err := link.getURI("theCode", "qrs", "")
+		targets, err := link.targets("theCode", "qrs", "")
 
 		assert.NoError(t, err)
-		assert.Equal(t, "https://api.test.com/theOrgId/codes/theCode/qrs", uri)
+		assert.Equal(t, []target{{base: "https://api.test.com/{organizationId}/codes/", uri: "https://api.test.com/theOrgId/codes/theCode/qrs"}}, targets)
 	})
 
 	t.Run("constructs_uri_with_all_prefixes", func(t *testing.T) {
@@ -178,10 +367,10 @@ func TestGetURI(t *testing.T) {
 			organizationID: "theOrgId",
 		}
 
-		uri, err := link.getURI("theCode", "qrs", "theQrId")
+		targets, err := link.targets("theCode", "qrs", "theQrId")
 
 		assert.NoError(t, err)
-		assert.Equal(t, "https://api.test.com/theOrgId/codes/theCode/qrs/theQrId", uri)
+		assert.Equal(t, []target{{base: "https://api.test.com/{organizationId}/codes/", uri: "https://api.test.com/theOrgId/codes/theCode/qrs/theQrId"}}, targets)
 	})
 }
 
@@ -446,14 +635,18 @@ func TestGetCodeStats(t *testing.T) {
 				Total:  100,
 				Unique: 75,
 				ByDay: []ClicksByDay{
-					{Date: "2024-01-01", Total: 50, Unique: 40},
-					{Date: "2024-01-02", Total: 50, Unique: 35},
+					{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Total: 50, Unique: 40},
+					{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Total: 50, Unique: 35},
 				},
 			},
-			Referrals: []any{},
-			Browsers:  []any{},
-			Devices:   []any{},
-			Locations: []any{},
+			Referrals: []ReferralStats{{URL: "https://news.example.com", Total: 60,
+				Raw: map[string]any{"url": "https://news.example.com", "total": 60.0}}},
+			Browsers: []BrowserStats{{Name: "Firefox", Total: 100,
+				Raw: map[string]any{"name": "Firefox", "total": 100.0}}},
+			Devices: []DeviceStats{{Name: "desktop", Total: 100,
+				Raw: map[string]any{"name": "desktop", "total": 100.0}}},
+			Locations: []LocationStats{{Country: "US", City: "Seattle", Total: 100, Unique: 75,
+				Raw: map[string]any{"country": "US", "city": "Seattle", "total": 100.0, "unique": 75.0}}},
 		}
 		responseBody, _ := json.Marshal(expectedResponse)
 		fakeClient := &FakeHTTPClient{
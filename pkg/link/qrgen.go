@@ -0,0 +1,333 @@
+package link
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/Hyphen/go-sdk/internal/qrcode"
+)
+
+// QRImageFormat is the image format of a locally generated QR code
+type QRImageFormat string
+
+const (
+	QRImagePNG QRImageFormat = "png"
+	QRImageSVG QRImageFormat = "svg"
+)
+
+// qrSizePixels is the width and height in pixels of each QR code size when
+// generated locally. The sizes are this SDK's choice, since the QR code
+// service does not document its dimensions, so a generated code may differ in
+// size from one created with CreateQRCode.
+var qrSizePixels = map[QRSize]int{
+	QRSizeSmall:  256,
+	QRSizeMedium: 512,
+	QRSizeLarge:  1024,
+}
+
+const (
+	// qrQuietZone is the light border around the symbol, in modules
+	qrQuietZone = 4
+	// qrLogoRatio is the largest fraction of the symbol width covered by a logo
+	qrLogoRatio = 0.2
+)
+
+// ShortURL returns the URL a short code redirects from
+func (s ShortCodeResponse) ShortURL() string {
+	if strings.Contains(s.Domain, "://") {
+		return strings.TrimSuffix(s.Domain, "/") + "/" + s.Code
+	}
+	return "https://" + s.Domain + "/" + s.Code
+}
+
+// GenerateQRCode renders a QR code for the short code's URL locally, without
+// calling the QR code service. Size, Color, BackgroundColor and Logo are
+// honored; colors are hex strings such as "#1a2b3c" and the logo must be a
+// data URI or base64 image, since nothing is fetched. A logo raises the error
+// correction level so the code still scans with its center covered. The image
+// is returned in QRCodeBytes and as a data URI in QRCode. Its dimensions and
+// styling may differ from those of a code created with CreateQRCode.
+func GenerateQRCode(code *ShortCodeResponse, format QRImageFormat, opts *CreateQRCodeOptions) (*QRCodeResponse, error) {
+	if code == nil || code.Domain == "" || code.Code == "" {
+		return nil, errors.New("failed to generate QR code: short code needs a domain and code")
+	}
+	if opts == nil {
+		opts = &CreateQRCodeOptions{}
+	}
+
+	style, err := newQRStyle(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	level := qrcode.Medium
+	if style.logo != nil {
+		level = qrcode.High
+	}
+	symbol, err := qrcode.Encode([]byte(code.ShortURL()), level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	var data []byte
+	var mimeType string
+	switch format {
+	case QRImagePNG, "":
+		data, err = renderQRPNG(symbol, style)
+		mimeType = "image/png"
+	case QRImageSVG:
+		data, err = renderQRSVG(symbol, style)
+		mimeType = mimeTypeSVG
+	default:
+		err = fmt.Errorf("unsupported image format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return &QRCodeResponse{
+		Title:          opts.Title,
+		QRCode:         "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		QRCodeBytes:    data,
+		QRCodeMIMEType: mimeType,
+	}, nil
+}
+
+// qrStyle is the parsed appearance of a generated QR code
+type qrStyle struct {
+	pixels       int
+	foreground   color.NRGBA
+	background   color.NRGBA
+	logo         image.Image // Decoded raster logo, used for PNG output
+	logoData     []byte      // Original logo bytes, embedded in SVG output
+	logoMIMEType string
+}
+
+func newQRStyle(opts *CreateQRCodeOptions) (*qrStyle, error) {
+	style := &qrStyle{
+		pixels:     qrSizePixels[QRSizeMedium],
+		foreground: color.NRGBA{A: 0xff},
+		background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+
+	if opts.Size != "" {
+		pixels, ok := qrSizePixels[opts.Size]
+		if !ok {
+			return nil, fmt.Errorf("unknown size %q", opts.Size)
+		}
+		style.pixels = pixels
+	}
+
+	var err error
+	if opts.Color != "" {
+		if style.foreground, err = parseHexColor(opts.Color); err != nil {
+			return nil, err
+		}
+	}
+	if opts.BackgroundColor != "" {
+		if style.background, err = parseHexColor(opts.BackgroundColor); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Logo != "" {
+		if strings.HasPrefix(opts.Logo, "http://") || strings.HasPrefix(opts.Logo, "https://") {
+			return nil, errors.New("logo must be a data URI or base64 image to generate QR codes offline")
+		}
+		style.logoData, style.logoMIMEType, err = decodeQRPayload(opts.Logo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logo: %w", err)
+		}
+		if style.logoMIMEType != mimeTypeSVG {
+			if style.logo, _, err = image.Decode(bytes.NewReader(style.logoData)); err != nil {
+				return nil, fmt.Errorf("invalid logo: %w", err)
+			}
+		}
+	}
+
+	return style, nil
+}
+
+// hasLogo reports whether a logo of any kind was given
+func (s *qrStyle) hasLogo() bool {
+	return s.logoData != nil
+}
+
+// parseHexColor parses #rgb, #rrggbb or #rrggbbaa, with or without the #
+func parseHexColor(s string) (color.NRGBA, error) {
+	digits := strings.TrimPrefix(s, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+
+	b, err := hex.DecodeString(digits)
+	if err != nil || len(b) != 4 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{R: b[0], G: b[1], B: b[2], A: b[3]}, nil
+}
+
+// qrLayout is the geometry shared by the PNG and SVG renderers, in modules
+type qrLayout struct {
+	modules  int // Symbol width plus the quiet zone on both sides
+	logo     int // Width of the logo area including its padding, zero for none
+	logoFrom int // First module of the logo area on both axes
+}
+
+func newQRLayout(symbol *qrcode.Code, style *qrStyle) qrLayout {
+	layout := qrLayout{modules: symbol.Size + 2*qrQuietZone}
+	if style.hasLogo() {
+		logo := int(float64(symbol.Size) * qrLogoRatio)
+		if (symbol.Size-logo)%2 != 0 {
+			logo-- // Keep the logo centered on whole modules
+		}
+		layout.logo = logo
+		layout.logoFrom = qrQuietZone + (symbol.Size-logo)/2
+	}
+	return layout
+}
+
+// covered reports whether the module at x, y lies under the logo
+func (l qrLayout) covered(x, y int) bool {
+	to := l.logoFrom + l.logo
+	return l.logo > 0 && x >= l.logoFrom && x < to && y >= l.logoFrom && y < to
+}
+
+// renderQRPNG draws the symbol as a PNG of the style's size
+func renderQRPNG(symbol *qrcode.Code, style *qrStyle) ([]byte, error) {
+	if style.hasLogo() && style.logo == nil {
+		return nil, errors.New("SVG logos can only be used with SVG output")
+	}
+
+	layout := newQRLayout(symbol, style)
+	scale := max(style.pixels/layout.modules, 1)
+	pixels := max(style.pixels, layout.modules*scale)
+	// Center the symbol when the size is not a multiple of the module count
+	offset := (pixels - layout.modules*scale) / 2
+
+	img := image.NewNRGBA(image.Rect(0, 0, pixels, pixels))
+	draw.Draw(img, img.Bounds(), image.NewUniform(style.background), image.Point{}, draw.Src)
+
+	foreground := image.NewUniform(style.foreground)
+	for y := range symbol.Size {
+		for x := range symbol.Size {
+			mx, my := x+qrQuietZone, y+qrQuietZone
+			if !symbol.Dark(x, y) || layout.covered(mx, my) {
+				continue
+			}
+			r := image.Rect(offset+mx*scale, offset+my*scale, offset+(mx+1)*scale, offset+(my+1)*scale)
+			draw.Draw(img, r, foreground, image.Point{}, draw.Src)
+		}
+	}
+
+	if layout.logo > 0 {
+		// Leave a module of padding between the logo and the symbol
+		from := offset + (layout.logoFrom+1)*scale
+		side := (layout.logo - 2) * scale
+		drawScaled(img, image.Rect(from, from, from+side, from+side), style.logo)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawScaled draws src over dst centered in r, scaled to fit while keeping its
+// aspect ratio. Each destination pixel averages the source pixels it covers.
+func drawScaled(dst draw.Image, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() || r.Empty() {
+		return
+	}
+
+	w, h := r.Dx(), r.Dy()
+	if sb.Dx()*h > sb.Dy()*w {
+		h = max(sb.Dy()*w/sb.Dx(), 1)
+	} else {
+		w = max(sb.Dx()*h/sb.Dy(), 1)
+	}
+	r = image.Rect(0, 0, w, h).Add(r.Min).Add(image.Pt((r.Dx()-w)/2, (r.Dy()-h)/2))
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		y0 := sb.Min.Y + y*sb.Dy()/h
+		y1 := max(sb.Min.Y+(y+1)*sb.Dy()/h, y0+1)
+		for x := range w {
+			x0 := sb.Min.X + x*sb.Dx()/w
+			x1 := max(sb.Min.X+(x+1)*sb.Dx()/w, x0+1)
+
+			var rs, gs, bs, as, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// Premultiplied components, so averaging weights by alpha
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					rs, gs, bs, as = rs+cr, gs+cg, bs+cb, as+ca
+					n++
+				}
+			}
+			scaled.Set(x, y, color.RGBA64{R: uint16(rs / n), G: uint16(gs / n), B: uint16(bs / n), A: uint16(as / n)})
+		}
+	}
+
+	draw.Draw(dst, r, scaled, image.Point{}, draw.Over)
+}
+
+// renderQRSVG draws the symbol as an SVG of the style's size, merging each
+// horizontal run of dark modules into one rectangle
+func renderQRSVG(symbol *qrcode.Code, style *qrStyle) ([]byte, error) {
+	layout := newQRLayout(symbol, style)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		style.pixels, style.pixels, layout.modules, layout.modules)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%"%s/>`, svgFill(style.background))
+
+	buf.WriteString(`<path d="`)
+	for y := range symbol.Size {
+		for x := 0; x < symbol.Size; {
+			mx, my := x+qrQuietZone, y+qrQuietZone
+			if !symbol.Dark(x, y) || layout.covered(mx, my) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < symbol.Size && symbol.Dark(x+run, y) && !layout.covered(mx+run, my) {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", mx, my, run, run)
+			x += run
+		}
+	}
+	fmt.Fprintf(&buf, `"%s/>`, svgFill(style.foreground))
+
+	if layout.logo > 0 {
+		fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="xMidYMid meet" href="data:%s;base64,%s"/>`,
+			layout.logoFrom+1, layout.logoFrom+1, layout.logo-2, layout.logo-2,
+			style.logoMIMEType, base64.StdEncoding.EncodeToString(style.logoData))
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+// svgFill formats a fill attribute, with an opacity for translucent colors
+func svgFill(c color.NRGBA) string {
+	fill := fmt.Sprintf(` fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%.3g"`, float64(c.A)/0xff)
+	}
+	return fill
+}
@@ -0,0 +1,126 @@
+package link
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateQRCode(t *testing.T) {
+	code := &ShortCodeResponse{Code: "abc", Domain: "h4n.link"}
+
+	t.Run("renders_the_short_url_as_a_png", func(t *testing.T) {
+		qr, err := GenerateQRCode(code, QRImagePNG, &CreateQRCodeOptions{Title: "Poster", Size: QRSizeSmall})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Poster", qr.Title)
+		assert.Equal(t, "image/png", qr.QRCodeMIMEType)
+		assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(qr.QRCodeBytes), qr.QRCode)
+
+		img, err := qr.Image()
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 256, 256), img.Bounds())
+
+		// Sample the center of every module and compare with the symbol
+		symbol, err := qrcode.Encode([]byte("https://h4n.link/abc"), qrcode.Medium)
+		require.NoError(t, err)
+		modules := symbol.Size + 2*qrQuietZone
+		scale := 256 / modules
+		offset := (256 - modules*scale) / 2
+		for y := -qrQuietZone; y < symbol.Size+qrQuietZone; y++ {
+			for x := -qrQuietZone; x < symbol.Size+qrQuietZone; x++ {
+				px := offset + (x+qrQuietZone)*scale + scale/2
+				py := offset + (y+qrQuietZone)*scale + scale/2
+				r, _, _, _ := img.At(px, py).RGBA()
+				require.Equal(t, symbol.Dark(x, y), r == 0, "module %d,%d", x, y)
+			}
+		}
+	})
+
+	t.Run("applies_colors", func(t *testing.T) {
+		qr, err := GenerateQRCode(code, QRImagePNG, &CreateQRCodeOptions{Color: "#1a2b3c", BackgroundColor: "fe0"})
+		require.NoError(t, err)
+		img, err := qr.Image()
+		require.NoError(t, err)
+
+		assert.Equal(t, image.Rect(0, 0, 512, 512), img.Bounds())
+		assert.Equal(t, color.NRGBA{R: 0xff, G: 0xee, B: 0x00, A: 0xff}, color.NRGBAModel.Convert(img.At(0, 0)))
+		// The top left finder pattern starts after the quiet zone
+		scale := 512 / (25 + 2*qrQuietZone)
+		offset := (512 - (25+2*qrQuietZone)*scale) / 2
+		corner := offset + qrQuietZone*scale
+		assert.Equal(t, color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}, color.NRGBAModel.Convert(img.At(corner, corner)))
+	})
+
+	t.Run("overlays_a_logo_with_high_error_correction", func(t *testing.T) {
+		logo := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+		for y := range 20 {
+			for x := range 40 {
+				logo.Set(x, y, color.NRGBA{R: 0xff, A: 0xff})
+			}
+		}
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, logo))
+		logoURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+		qr, err := GenerateQRCode(code, QRImagePNG, &CreateQRCodeOptions{Logo: logoURI})
+		require.NoError(t, err)
+		img, err := qr.Image()
+		require.NoError(t, err)
+
+		assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, color.NRGBAModel.Convert(img.At(256, 256)))
+		// A wide logo keeps its aspect ratio, leaving background above it
+		assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.NRGBAModel.Convert(img.At(256, 256-30)))
+	})
+
+	t.Run("renders_svg", func(t *testing.T) {
+		logo := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`))
+
+		qr, err := GenerateQRCode(code, QRImageSVG, &CreateQRCodeOptions{
+			Size:            QRSizeLarge,
+			Color:           "#00000080",
+			BackgroundColor: "#ffffff",
+			Logo:            logo,
+		})
+		require.NoError(t, err)
+
+		svg := string(qr.QRCodeBytes)
+		assert.Equal(t, "image/svg+xml", qr.QRCodeMIMEType)
+		assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="1024" height="1024"`))
+		assert.Contains(t, svg, `<rect width="100%" height="100%" fill="#ffffff"/>`)
+		assert.Contains(t, svg, `fill="#000000" fill-opacity="0.502"/>`)
+		assert.Contains(t, svg, `href="`+logo+`"/>`)
+	})
+
+	t.Run("rejects_invalid_options", func(t *testing.T) {
+		tests := []struct {
+			opts *CreateQRCodeOptions
+			err  string
+		}{
+			{&CreateQRCodeOptions{Size: "huge"}, `failed to generate QR code: unknown size "huge"`},
+			{&CreateQRCodeOptions{Color: "blue"}, `failed to generate QR code: invalid color "blue"`},
+			{&CreateQRCodeOptions{Logo: "https://example.com/logo.png"}, "failed to generate QR code: logo must be a data URI or base64 image to generate QR codes offline"},
+			{&CreateQRCodeOptions{Logo: "data:image/svg+xml,%3Csvg%2F%3E"}, "failed to generate QR code: SVG logos can only be used with SVG output"},
+		}
+		for _, tt := range tests {
+			_, err := GenerateQRCode(code, QRImagePNG, tt.opts)
+			assert.EqualError(t, err, tt.err)
+		}
+
+		_, err := GenerateQRCode(&ShortCodeResponse{Code: "abc"}, QRImagePNG, nil)
+		assert.EqualError(t, err, "failed to generate QR code: short code needs a domain and code")
+	})
+}
+
+func TestShortURL(t *testing.T) {
+	assert.Equal(t, "https://h4n.link/abc", ShortCodeResponse{Domain: "h4n.link", Code: "abc"}.ShortURL())
+	assert.Equal(t, "http://localhost:8080/abc", ShortCodeResponse{Domain: "http://localhost:8080/", Code: "abc"}.ShortURL())
+}
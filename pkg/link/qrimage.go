@@ -0,0 +1,180 @@
+package link
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+
+	// Register the formats the QR code service returns with image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ErrNoQRCodeImage is returned by the image helpers when a QR code response
+// carries no decodable image
+var ErrNoQRCodeImage = errors.New("QR code has no image data")
+
+// mimeTypeSVG is the MIME type of SVG images, which content sniffing reports as XML
+const mimeTypeSVG = "image/svg+xml"
+
+// base64Encodings are tried in turn for payloads that are not data URIs
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// decodeQRImage decodes the image of a QR code response. A payload that cannot
+// be decoded is logged rather than failing the request, leaving QRCodeBytes
+// empty.
+func (l *Link) decodeQRImage(qr *QRCodeResponse) {
+	if err := qr.decodeImage(); err != nil {
+		l.log().Warn("could not decode QR code image",
+			slog.String("qr_id", qr.ID),
+			slog.Any(client.LogKeyError, err),
+		)
+	}
+}
+
+// decodeImage fills in QRCodeBytes and QRCodeMIMEType from the QRCode string,
+// which is either a data URI or a bare base64 image
+func (q *QRCodeResponse) decodeImage() error {
+	q.QRCodeBytes = nil
+	q.QRCodeMIMEType = ""
+	if q.QRCode == "" {
+		return nil
+	}
+
+	data, mimeType, err := decodeQRPayload(q.QRCode)
+	if err != nil {
+		return err
+	}
+
+	q.QRCodeBytes = data
+	q.QRCodeMIMEType = mimeType
+	return nil
+}
+
+// decodeQRPayload decodes a data URI or bare base64 string into image bytes
+// and their MIME type
+func decodeQRPayload(payload string) ([]byte, string, error) {
+	if rest, ok := strings.CutPrefix(payload, "data:"); ok {
+		return decodeDataURI(rest)
+	}
+
+	data, err := decodeBase64(payload)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mimeType := detectImageType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", fmt.Errorf("QR code payload is %s, not an image", mimeType)
+	}
+	return data, mimeType, nil
+}
+
+// decodeDataURI decodes the part of a data URI after the "data:" scheme
+func decodeDataURI(uri string) ([]byte, string, error) {
+	header, payload, ok := strings.Cut(uri, ",")
+	if !ok {
+		return nil, "", errors.New("QR code data URI has no payload")
+	}
+
+	mimeType, params, _ := strings.Cut(header, ";")
+	isBase64 := false
+	for _, param := range strings.Split(params, ";") {
+		if param == "base64" {
+			isBase64 = true
+		}
+	}
+
+	var data []byte
+	var err error
+	if isBase64 {
+		data, err = decodeBase64(payload)
+	} else {
+		var text string
+		text, err = url.PathUnescape(payload)
+		data = []byte(text)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if mimeType == "" {
+		mimeType = detectImageType(data)
+	}
+	return data, mimeType, nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not
+func decodeBase64(payload string) ([]byte, error) {
+	payload = strings.Join(strings.Fields(payload), "")
+	for _, encoding := range base64Encodings {
+		if data, err := encoding.DecodeString(payload); err == nil {
+			return data, nil
+		}
+	}
+	return nil, errors.New("QR code payload is not valid base64")
+}
+
+// detectImageType sniffs the MIME type of image bytes
+func detectImageType(data []byte) string {
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") && bytes.Contains(data[:min(len(data), 512)], []byte("<svg")) {
+		return mimeTypeSVG
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return mimeType
+}
+
+// WriteTo writes the decoded QR code image to w
+func (q *QRCodeResponse) WriteTo(w io.Writer) (int64, error) {
+	if len(q.QRCodeBytes) == 0 {
+		return 0, ErrNoQRCodeImage
+	}
+	n, err := w.Write(q.QRCodeBytes)
+	return int64(n), err
+}
+
+// SaveToFile writes the decoded QR code image to the named file, creating or
+// truncating it
+func (q *QRCodeResponse) SaveToFile(path string) error {
+	if len(q.QRCodeBytes) == 0 {
+		return ErrNoQRCodeImage
+	}
+	if err := os.WriteFile(path, q.QRCodeBytes, 0o644); err != nil {
+		return fmt.Errorf("failed to save QR code: %w", err)
+	}
+	return nil
+}
+
+// Image decodes the QR code into an image.Image. PNG, JPEG and GIF images are
+// supported; SVG images must be written out with WriteTo or SaveToFile instead.
+func (q *QRCodeResponse) Image() (image.Image, error) {
+	if len(q.QRCodeBytes) == 0 {
+		return nil, ErrNoQRCodeImage
+	}
+	if q.QRCodeMIMEType == mimeTypeSVG {
+		return nil, fmt.Errorf("cannot decode %s QR code as an image", mimeTypeSVG)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(q.QRCodeBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode QR code image: %w", err)
+	}
+	return img, nil
+}
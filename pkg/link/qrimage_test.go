@@ -0,0 +1,103 @@
+package link
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG encodes a 2x2 black and white image
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.SetGray(1, 0, color.Gray{Y: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestQRCodeImage(t *testing.T) {
+	pngData := testPNG(t)
+
+	t.Run("decodes_a_data_uri_from_CreateQRCode", func(t *testing.T) {
+		responseBody, _ := json.Marshal(QRCodeResponse{
+			ID:     "theQrId",
+			QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData),
+		})
+		link := &Link{
+			uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+			organizationID: "theOrgId",
+			client: &FakeHTTPClient{
+				PostFake: func(ctx context.Context, url string, body interface{}, headers map[string]string) (*client.Response, error) {
+					return &client.Response{StatusCode: http.StatusCreated, Body: responseBody}, nil
+				},
+			},
+		}
+
+		qr, err := link.CreateQRCode(context.Background(), "theCode", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, pngData, qr.QRCodeBytes)
+		assert.Equal(t, "image/png", qr.QRCodeMIMEType)
+	})
+
+	t.Run("detects_the_type_of_bare_base64", func(t *testing.T) {
+		qr := QRCodeResponse{QRCode: base64.RawURLEncoding.EncodeToString(pngData)}
+
+		require.NoError(t, qr.decodeImage())
+
+		assert.Equal(t, pngData, qr.QRCodeBytes)
+		assert.Equal(t, "image/png", qr.QRCodeMIMEType)
+	})
+
+	t.Run("decodes_percent_encoded_svg", func(t *testing.T) {
+		qr := QRCodeResponse{QRCode: "data:image/svg+xml,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%2F%3E"}
+
+		require.NoError(t, qr.decodeImage())
+
+		assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg"/>`, string(qr.QRCodeBytes))
+		_, err := qr.Image()
+		assert.EqualError(t, err, "cannot decode image/svg+xml QR code as an image")
+	})
+
+	t.Run("leaves_bytes_empty_for_payloads_that_are_not_images", func(t *testing.T) {
+		qr := QRCodeResponse{QRCode: base64.StdEncoding.EncodeToString([]byte("hello"))}
+
+		assert.EqualError(t, qr.decodeImage(), "QR code payload is text/plain, not an image")
+		assert.Nil(t, qr.QRCodeBytes)
+		_, err := qr.Image()
+		assert.ErrorIs(t, err, ErrNoQRCodeImage)
+	})
+
+	t.Run("writes_saves_and_decodes_the_image", func(t *testing.T) {
+		qr := QRCodeResponse{QRCodeBytes: pngData, QRCodeMIMEType: "image/png"}
+
+		var buf bytes.Buffer
+		n, err := qr.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(pngData)), n)
+		assert.Equal(t, pngData, buf.Bytes())
+
+		path := filepath.Join(t.TempDir(), "qr.png")
+		require.NoError(t, qr.SaveToFile(path))
+		saved, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, pngData, saved)
+
+		img, err := qr.Image()
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 2, 2), img.Bounds())
+	})
+}
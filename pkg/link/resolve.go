@@ -0,0 +1,271 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultMaxHops is how many redirects Inspect follows when none is configured
+	defaultMaxHops = 10
+	// resolveTimeout bounds each request of the default resolver
+	resolveTimeout = 10 * time.Second
+)
+
+// ErrTooManyRedirects is returned when a redirect chain is longer than allowed
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// InspectOptions configures Inspect and ResolveShortCode
+type InspectOptions struct {
+	MaxHops int // Maximum number of redirects to follow, defaults to 10
+}
+
+// Hop is one request of a redirect chain
+type Hop struct {
+	URL        string // The requested URL
+	StatusCode int    // The response status
+	Location   string // Absolute redirect target, empty for the final hop
+}
+
+// Resolution is the redirect chain of a URL
+type Resolution struct {
+	URL         string // The URL inspection started from
+	Hops        []Hop  // Every request made, in order
+	FinalURL    string // The URL the chain ended on
+	StatusCode  int    // Status of the final hop
+	ExpectedURL string // The long URL of the short code, empty for Inspect
+	Matches     bool   // Whether any hop requested ExpectedURL, ignoring case in scheme and host and default ports
+}
+
+// Inspect follows the redirect chain of rawURL and reports every hop. Each
+// URL is requested with HEAD, falling back to GET for servers that refuse
+// HEAD; response bodies are never read. On error the returned Resolution holds
+// the hops made so far.
+func (l *Link) Inspect(ctx context.Context, rawURL string, opts *InspectOptions) (*Resolution, error) {
+	resolution, err := l.resolve(ctx, rawURL, "", opts)
+	if err != nil {
+		l.emitError(err)
+	}
+	return resolution, err
+}
+
+// ResolveShortCode looks up the short code with the given ID, as returned in
+// ShortCodeResponse.ID rather than the code in its URL, and follows its short
+// URL, reporting whether the chain passes through the stored long URL. The
+// long URL may redirect further, for example from http to https.
+func (l *Link) ResolveShortCode(ctx context.Context, id string, opts *InspectOptions) (*Resolution, error) {
+	shortCode, err := l.GetShortCode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution, err := l.resolve(ctx, shortCode.ShortURL(), shortCode.LongURL, opts)
+	if err != nil {
+		l.emitError(err)
+	}
+	return resolution, err
+}
+
+// resolve follows a redirect chain without reporting errors, looking for
+// expectedURL among its hops when that is set
+func (l *Link) resolve(ctx context.Context, rawURL, expectedURL string, opts *InspectOptions) (*Resolution, error) {
+	maxHops := defaultMaxHops
+	if opts != nil && opts.MaxHops > 0 {
+		maxHops = opts.MaxHops
+	}
+
+	resolution := &Resolution{URL: rawURL, ExpectedURL: expectedURL}
+	current := rawURL
+	for {
+		u, err := url.Parse(current)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return resolution, fmt.Errorf("failed to inspect %s: cannot follow %q", rawURL, current)
+		}
+
+		status, location, err := l.head(ctx, current)
+		if err != nil {
+			return resolution, fmt.Errorf("failed to inspect %s: %w", rawURL, err)
+		}
+
+		hop := Hop{URL: current, StatusCode: status}
+		if status >= 300 && status < 400 && location != "" {
+			target, err := u.Parse(location)
+			if err != nil {
+				return resolution, fmt.Errorf("failed to inspect %s: invalid redirect to %q", rawURL, location)
+			}
+			hop.Location = target.String()
+		}
+		resolution.Hops = append(resolution.Hops, hop)
+		if expectedURL != "" && sameURL(current, expectedURL) {
+			resolution.Matches = true
+		}
+
+		if hop.Location == "" {
+			resolution.FinalURL = current
+			resolution.StatusCode = status
+			return resolution, nil
+		}
+		if len(resolution.Hops) > maxHops {
+			return resolution, fmt.Errorf("failed to inspect %s: %w", rawURL, ErrTooManyRedirects)
+		}
+		current = hop.Location
+	}
+}
+
+// head requests rawURL without following redirects or reading the body,
+// retrying with GET when the server refuses HEAD
+func (l *Link) head(ctx context.Context, rawURL string) (status int, location string, err error) {
+	send := func(method string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := l.resolverClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return resp, nil
+	}
+
+	resp, err := send(http.MethodHead)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = send(http.MethodGet)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, resp.Header.Get("Location"), nil
+}
+
+// resolverClient returns the HTTP client used to follow redirect chains
+func (l *Link) resolverClient() *http.Client {
+	if l.resolver != nil {
+		return l.resolver
+	}
+	return newResolver(nil)
+}
+
+// newResolver copies base, or a default client, so that it reports redirects
+// instead of following them
+func newResolver(base *http.Client) *http.Client {
+	resolver := &http.Client{Timeout: resolveTimeout}
+	if base != nil {
+		copied := *base
+		resolver = &copied
+	}
+	resolver.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return resolver
+}
+
+// sameURL compares URLs ignoring case in the scheme and host, default ports
+// and an empty path
+func sameURL(a, b string) bool {
+	normalize := func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return raw
+		}
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+			u.Host = u.Hostname()
+		}
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		return u.String()
+	}
+	return normalize(a) == normalize(b)
+}
+
+// CheckOptions configures CheckLinks
+type CheckOptions struct {
+	Tags    []string      // Only check codes carrying all of these tags
+	MaxHops int           // Maximum number of redirects to follow, defaults to 10
+	Batch   *BatchOptions // Concurrency and rate limit of the checks
+}
+
+// LinkCheck is the result of checking one short code
+type LinkCheck struct {
+	ShortCode  ShortCodeResponse
+	Resolution *Resolution // The redirect chain, partial when Err is set and nil when never checked
+	Err        error       // Why the chain could not be followed
+	Problem    string      // Why the link is broken, empty when it is fine
+}
+
+// Broken reports whether the short code fails to land on its long URL
+func (c LinkCheck) Broken() bool {
+	return c.Problem != ""
+}
+
+// LinkChecks holds link check results in listing order
+type LinkChecks []LinkCheck
+
+// Broken returns the checks of broken links
+func (c LinkChecks) Broken() LinkChecks {
+	var broken LinkChecks
+	for _, check := range c {
+		if check.Broken() {
+			broken = append(broken, check)
+		}
+	}
+	return broken
+}
+
+// CheckLinks follows the short URL of every short code and flags those that
+// fail, end on an error status or never pass through their long URL. The
+// error is only set when the short codes cannot be listed; broken links are
+// reported in the results. Codes not checked before ctx is done carry the
+// context's error. The short URLs are built from the listing, so no short code
+// is looked up by ID.
+func (l *Link) CheckLinks(ctx context.Context, opts *CheckOptions) (LinkChecks, error) {
+	if opts == nil {
+		opts = &CheckOptions{}
+	}
+
+	var checks LinkChecks
+	for code, err := range l.ShortCodes(ctx, &ShortCodesOptions{Tags: opts.Tags}) {
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, LinkCheck{ShortCode: code})
+	}
+
+	inspectOpts := &InspectOptions{MaxHops: opts.MaxHops}
+	indexes := make([]int, len(checks))
+	for i := range checks {
+		indexes[i] = i
+	}
+
+	runParallel(ctx, indexes, opts.Batch, func(i int) {
+		check := &checks[i]
+		check.Resolution, check.Err = l.resolve(ctx, check.ShortCode.ShortURL(), check.ShortCode.LongURL, inspectOpts)
+		check.Problem = problem(check.Resolution, check.Err)
+	}, func(i int, err error) {
+		checks[i].Err = err
+		checks[i].Problem = problem(nil, err)
+	})
+
+	return checks, nil
+}
+
+// problem describes why a resolved short code is broken
+func problem(resolution *Resolution, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case resolution.StatusCode >= 300:
+		return fmt.Sprintf("%s returned HTTP %d", resolution.FinalURL, resolution.StatusCode)
+	case !resolution.Matches:
+		return fmt.Sprintf("lands on %s without passing %s", resolution.FinalURL, resolution.ExpectedURL)
+	}
+	return ""
+}
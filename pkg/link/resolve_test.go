@@ -0,0 +1,158 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redirectServer serves a small redirect graph. GET-only paths answer HEAD
+// with 405.
+func redirectServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/hop", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/hop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/landing?ref=short", http.StatusFound)
+	})
+	mux.HandleFunc("/landing", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("welcome"))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/missing", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInspect(t *testing.T) {
+	server := redirectServer(t)
+	link := &Link{resolver: newResolver(server.Client())}
+
+	t.Run("reports_every_hop", func(t *testing.T) {
+		resolution, err := link.Inspect(context.Background(), server.URL+"/short", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, []Hop{
+			{URL: server.URL + "/short", StatusCode: http.StatusMovedPermanently, Location: server.URL + "/hop"},
+			{URL: server.URL + "/hop", StatusCode: http.StatusFound, Location: server.URL + "/landing?ref=short"},
+			{URL: server.URL + "/landing?ref=short", StatusCode: http.StatusOK},
+		}, resolution.Hops)
+		assert.Equal(t, server.URL+"/landing?ref=short", resolution.FinalURL)
+		assert.Equal(t, http.StatusOK, resolution.StatusCode)
+		assert.False(t, resolution.Matches, "Inspect has no expected URL")
+	})
+
+	t.Run("stops_after_max_hops", func(t *testing.T) {
+		var handled error
+		link := &Link{resolver: newResolver(server.Client()), errorHandler: func(err error) { handled = err }}
+
+		resolution, err := link.Inspect(context.Background(), server.URL+"/loop", &InspectOptions{MaxHops: 3})
+
+		assert.ErrorIs(t, err, ErrTooManyRedirects)
+		assert.Equal(t, err, handled)
+		assert.Len(t, resolution.Hops, 4)
+	})
+
+	t.Run("rejects_non_http_urls", func(t *testing.T) {
+		_, err := link.Inspect(context.Background(), "mailto:someone@example.com", nil)
+
+		assert.EqualError(t, err, `failed to inspect mailto:someone@example.com: cannot follow "mailto:someone@example.com"`)
+	})
+}
+
+func TestResolveShortCode(t *testing.T) {
+	server := redirectServer(t)
+	link := &Link{
+		uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+		organizationID: "theOrgId",
+		resolver:       newResolver(server.Client()),
+		client: &FakeHTTPClient{
+			GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+				assert.Equal(t, "https://api.test.com/theOrgId/codes/theId", url)
+				body, _ := json.Marshal(ShortCodeResponse{ID: "theId", Code: "short", Domain: server.URL, LongURL: server.URL + "/landing?ref=short"})
+				return &client.Response{StatusCode: http.StatusOK, Body: body}, nil
+			},
+		},
+	}
+
+	resolution, err := link.ResolveShortCode(context.Background(), "theId", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/short", resolution.URL)
+	assert.Equal(t, server.URL+"/landing?ref=short", resolution.ExpectedURL)
+	assert.True(t, resolution.Matches)
+}
+
+func TestCheckLinks(t *testing.T) {
+	server := redirectServer(t)
+	codes := []ShortCodeResponse{
+		{Code: "short", Domain: server.URL, LongURL: server.URL + "/landing?ref=short"},
+		{Code: "hop", Domain: server.URL, LongURL: server.URL + "/elsewhere"},
+		{Code: "gone", Domain: server.URL, LongURL: server.URL + "/missing"},
+		{Code: "short", Domain: "http://127.0.0.1:1", LongURL: "https://example.com"},
+		{Code: "short", Domain: server.URL, LongURL: server.URL + "/hop"}, // The long URL redirects on
+	}
+	link := &Link{
+		uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+		organizationID: "theOrgId",
+		resolver:       newResolver(server.Client()),
+		client: &FakeHTTPClient{
+			GetFake: func(ctx context.Context, url string, headers map[string]string) (*client.Response, error) {
+				assert.Contains(t, url, "tags=campaign")
+				body, _ := json.Marshal(GetShortCodesResponse{Total: len(codes), Data: codes})
+				return &client.Response{StatusCode: http.StatusOK, Body: body}, nil
+			},
+		},
+	}
+
+	t.Run("flags_broken_links", func(t *testing.T) {
+		checks, err := link.CheckLinks(context.Background(), &CheckOptions{Tags: []string{"campaign"}, Batch: &BatchOptions{Concurrency: 2}})
+
+		require.NoError(t, err)
+		require.Len(t, checks, 5)
+		assert.False(t, checks[0].Broken())
+		assert.Equal(t, "lands on "+server.URL+"/landing?ref=short without passing "+server.URL+"/elsewhere", checks[1].Problem)
+		assert.Equal(t, server.URL+"/missing returned HTTP 404", checks[2].Problem)
+		assert.Error(t, checks[3].Err)
+		assert.Equal(t, checks[3].Err.Error(), checks[3].Problem)
+		assert.False(t, checks[4].Broken())
+		assert.Len(t, checks.Broken(), 3)
+	})
+
+	t.Run("stops_checking_when_cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		checks, err := link.CheckLinks(ctx, &CheckOptions{Tags: []string{"campaign"}})
+
+		require.NoError(t, err)
+		for _, check := range checks {
+			assert.ErrorIs(t, check.Err, context.Canceled)
+			assert.Nil(t, check.Resolution)
+		}
+		assert.Len(t, checks.Broken(), 5)
+	})
+}
+
+func TestSameURL(t *testing.T) {
+	assert.True(t, sameURL("HTTPS://Example.com:443", "https://example.com/"))
+	assert.True(t, sameURL("http://example.com:80/a?b=1", "http://EXAMPLE.com/a?b=1"))
+	assert.False(t, sameURL("https://example.com/a", "https://example.com/a/"))
+	assert.False(t, sameURL("https://example.com/?a=1&b=2", "https://example.com/?b=2&a=1"))
+}
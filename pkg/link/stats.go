@@ -0,0 +1,250 @@
+package link
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// dateLayout is the layout of day-granularity dates in statistics responses
+const dateLayout = "2006-01-02"
+
+// clicksByDayJSON is the wire form of ClicksByDay
+type clicksByDayJSON struct {
+	Date   string `json:"date"`
+	Total  int    `json:"total"`
+	Unique int    `json:"unique"`
+}
+
+// UnmarshalJSON parses the date as either a plain date or an RFC 3339 timestamp
+func (d *ClicksByDay) UnmarshalJSON(data []byte) error {
+	var raw clicksByDayJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = ClicksByDay{Total: raw.Total, Unique: raw.Unique}
+	if raw.Date == "" {
+		return nil
+	}
+
+	date, err := time.Parse(dateLayout, raw.Date)
+	if err != nil {
+		if date, err = time.Parse(time.RFC3339Nano, raw.Date); err != nil {
+			return fmt.Errorf("invalid click date %q", raw.Date)
+		}
+	}
+	d.Date = date
+	return nil
+}
+
+// MarshalJSON writes midnight UTC dates as plain dates and anything else as an
+// RFC 3339 timestamp
+func (d ClicksByDay) MarshalJSON() ([]byte, error) {
+	raw := clicksByDayJSON{Total: d.Total, Unique: d.Unique}
+	switch {
+	case d.Date.IsZero():
+	case d.Date.Location() == time.UTC && d.Date.Equal(d.Date.Truncate(24*time.Hour)):
+		raw.Date = d.Date.Format(dateLayout)
+	default:
+		raw.Date = d.Date.Format(time.RFC3339Nano)
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes the typed fields and keeps the whole entry in Raw, so
+// fields the types do not cover are still available
+func (r *ReferralStats) UnmarshalJSON(data []byte) error {
+	type plain ReferralStats
+	return unmarshalWithRaw(data, (*plain)(r), &r.Raw)
+}
+
+// UnmarshalJSON decodes the typed fields and keeps the whole entry in Raw
+func (b *BrowserStats) UnmarshalJSON(data []byte) error {
+	type plain BrowserStats
+	return unmarshalWithRaw(data, (*plain)(b), &b.Raw)
+}
+
+// UnmarshalJSON decodes the typed fields and keeps the whole entry in Raw
+func (d *DeviceStats) UnmarshalJSON(data []byte) error {
+	type plain DeviceStats
+	return unmarshalWithRaw(data, (*plain)(d), &d.Raw)
+}
+
+// UnmarshalJSON decodes the typed fields and keeps the whole entry in Raw
+func (l *LocationStats) UnmarshalJSON(data []byte) error {
+	type plain LocationStats
+	return unmarshalWithRaw(data, (*plain)(l), &l.Raw)
+}
+
+// unmarshalWithRaw decodes data into typed and also into raw
+func unmarshalWithRaw(data []byte, typed any, raw *map[string]any) error {
+	if err := json.Unmarshal(data, typed); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, raw)
+}
+
+// ClickTotals is a number of total and unique clicks. Unique clicks summed over
+// several days count a visitor once per day.
+type ClickTotals struct {
+	Total  int `json:"total"`
+	Unique int `json:"unique"`
+}
+
+// ClicksPeriod is the click totals of a week or month
+type ClicksPeriod struct {
+	Start time.Time // First instant of the period
+	End   time.Time // First instant after the period
+	ClickTotals
+}
+
+// Totals returns the overall click totals
+func (c ClicksStats) Totals() ClickTotals {
+	return ClickTotals{Total: c.Total, Unique: c.Unique}
+}
+
+// Sum adds up the daily clicks dated from start through end inclusive
+func (c ClicksStats) Sum(start, end time.Time) ClickTotals {
+	var totals ClickTotals
+	for _, day := range c.ByDay {
+		if day.Date.Before(start) || day.Date.After(end) {
+			continue
+		}
+		totals.Total += day.Total
+		totals.Unique += day.Unique
+	}
+	return totals
+}
+
+// ByWeek rolls the daily clicks up into weeks starting on Monday, in
+// chronological order
+func (c ClicksStats) ByWeek() []ClicksPeriod {
+	return c.rollup(func(date time.Time) (time.Time, time.Time) {
+		start := startOfDay(date)
+		// Weekday counts from Sunday; shift so Monday is the first day
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	})
+}
+
+// ByMonth rolls the daily clicks up into calendar months, in chronological
+// order
+func (c ClicksStats) ByMonth() []ClicksPeriod {
+	return c.rollup(func(date time.Time) (time.Time, time.Time) {
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		return start, start.AddDate(0, 1, 0)
+	})
+}
+
+// rollup groups daily clicks into the period returned by bounds for each date
+func (c ClicksStats) rollup(bounds func(time.Time) (time.Time, time.Time)) []ClicksPeriod {
+	var periods []ClicksPeriod
+	index := map[time.Time]int{}
+	for _, day := range c.ByDay {
+		start, end := bounds(day.Date)
+		i, ok := index[start]
+		if !ok {
+			i = len(periods)
+			index[start] = i
+			periods = append(periods, ClicksPeriod{Start: start, End: end})
+		}
+		periods[i].Total += day.Total
+		periods[i].Unique += day.Unique
+	}
+
+	slices.SortFunc(periods, func(a, b ClicksPeriod) int {
+		return a.Start.Compare(b.Start)
+	})
+	return periods
+}
+
+// startOfDay returns midnight of the date in its own location
+func startOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+// ClicksComparison compares the clicks of two periods
+type ClicksComparison struct {
+	Current  ClickTotals
+	Previous ClickTotals
+}
+
+// Compare compares the daily clicks of the current period with those of the
+// previous one, each bounded inclusively like Sum
+func (c ClicksStats) Compare(currentStart, currentEnd, previousStart, previousEnd time.Time) ClicksComparison {
+	return ClicksComparison{
+		Current:  c.Sum(currentStart, currentEnd),
+		Previous: c.Sum(previousStart, previousEnd),
+	}
+}
+
+// TotalDelta returns the difference in total clicks
+func (c ClicksComparison) TotalDelta() int {
+	return c.Current.Total - c.Previous.Total
+}
+
+// UniqueDelta returns the difference in unique clicks
+func (c ClicksComparison) UniqueDelta() int {
+	return c.Current.Unique - c.Previous.Unique
+}
+
+// TotalChange returns the relative change in total clicks, where 0.5 means 50%
+// more. It returns false when the previous period had no clicks.
+func (c ClicksComparison) TotalChange() (float64, bool) {
+	return relativeChange(c.Current.Total, c.Previous.Total)
+}
+
+// UniqueChange returns the relative change in unique clicks like TotalChange
+func (c ClicksComparison) UniqueChange() (float64, bool) {
+	return relativeChange(c.Current.Unique, c.Previous.Unique)
+}
+
+func relativeChange(current, previous int) (float64, bool) {
+	if previous == 0 {
+		return 0, false
+	}
+	return float64(current-previous) / float64(previous), true
+}
+
+// TopReferrers returns the n referrers with the most clicks, or all of them
+// when n is not positive
+func (s *GetCodeStatsResponse) TopReferrers(n int) []ReferralStats {
+	referrals := slices.Clone(s.Referrals)
+	slices.SortStableFunc(referrals, func(a, b ReferralStats) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.URL, b.URL))
+	})
+	return top(referrals, n)
+}
+
+// TopCountries returns the n countries with the most clicks, or all of them
+// when n is not positive. Locations in the same country are combined.
+func (s *GetCodeStatsResponse) TopCountries(n int) []LocationStats {
+	var countries []LocationStats
+	index := map[string]int{}
+	for _, location := range s.Locations {
+		i, ok := index[location.Country]
+		if !ok {
+			i = len(countries)
+			index[location.Country] = i
+			countries = append(countries, LocationStats{Country: location.Country})
+		}
+		countries[i].Total += location.Total
+		countries[i].Unique += location.Unique
+	}
+
+	slices.SortStableFunc(countries, func(a, b LocationStats) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Country, b.Country))
+	})
+	return top(countries, n)
+}
+
+// top truncates a sorted slice to its first n entries
+func top[T any](items []T, n int) []T {
+	if n > 0 && n < len(items) {
+		return items[:n]
+	}
+	return items
+}
@@ -0,0 +1,153 @@
+package link
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestClicksByDayJSON(t *testing.T) {
+	t.Run("parses_plain_dates_and_timestamps", func(t *testing.T) {
+		var days []ClicksByDay
+		err := json.Unmarshal([]byte(`[
+			{"date": "2024-01-01", "total": 3, "unique": 2},
+			{"date": "2024-01-02T12:30:00Z", "total": 1, "unique": 1}
+		]`), &days)
+
+		require.NoError(t, err)
+		assert.Equal(t, day(2024, 1, 1), days[0].Date)
+		assert.Equal(t, time.Date(2024, 1, 2, 12, 30, 0, 0, time.UTC), days[1].Date)
+		assert.Equal(t, 3, days[0].Total)
+	})
+
+	t.Run("rejects_invalid_dates", func(t *testing.T) {
+		var d ClicksByDay
+		err := json.Unmarshal([]byte(`{"date": "yesterday"}`), &d)
+
+		assert.EqualError(t, err, `invalid click date "yesterday"`)
+	})
+
+	t.Run("writes_midnight_dates_as_plain_dates", func(t *testing.T) {
+		data, err := json.Marshal([]ClicksByDay{
+			{Date: day(2024, 1, 1), Total: 3, Unique: 2},
+			{Date: time.Date(2024, 1, 2, 12, 30, 0, 0, time.UTC), Total: 1, Unique: 1},
+		})
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"date": "2024-01-01", "total": 3, "unique": 2},
+			{"date": "2024-01-02T12:30:00Z", "total": 1, "unique": 1}
+		]`, string(data))
+	})
+}
+
+func TestClicksStatsAggregation(t *testing.T) {
+	clicks := ClicksStats{
+		Total:  100,
+		Unique: 60,
+		ByDay: []ClicksByDay{
+			{Date: day(2024, 1, 29), Total: 10, Unique: 5}, // Monday
+			{Date: day(2024, 1, 31), Total: 20, Unique: 10},
+			{Date: day(2024, 2, 4), Total: 30, Unique: 20}, // Sunday
+			{Date: day(2024, 2, 5), Total: 40, Unique: 25}, // Monday
+		},
+	}
+
+	t.Run("sums_an_inclusive_date_range", func(t *testing.T) {
+		assert.Equal(t, ClickTotals{Total: 50, Unique: 30}, clicks.Sum(day(2024, 1, 31), day(2024, 2, 4)))
+		assert.Equal(t, ClickTotals{}, clicks.Sum(day(2024, 3, 1), day(2024, 3, 31)))
+	})
+
+	t.Run("rolls_up_by_week_starting_monday", func(t *testing.T) {
+		assert.Equal(t, []ClicksPeriod{
+			{Start: day(2024, 1, 29), End: day(2024, 2, 5), ClickTotals: ClickTotals{Total: 60, Unique: 35}},
+			{Start: day(2024, 2, 5), End: day(2024, 2, 12), ClickTotals: ClickTotals{Total: 40, Unique: 25}},
+		}, clicks.ByWeek())
+	})
+
+	t.Run("rolls_up_by_month", func(t *testing.T) {
+		assert.Equal(t, []ClicksPeriod{
+			{Start: day(2024, 1, 1), End: day(2024, 2, 1), ClickTotals: ClickTotals{Total: 30, Unique: 15}},
+			{Start: day(2024, 2, 1), End: day(2024, 3, 1), ClickTotals: ClickTotals{Total: 70, Unique: 45}},
+		}, clicks.ByMonth())
+	})
+
+	t.Run("compares_two_periods", func(t *testing.T) {
+		comparison := clicks.Compare(day(2024, 2, 1), day(2024, 2, 29), day(2024, 1, 1), day(2024, 1, 31))
+
+		assert.Equal(t, ClickTotals{Total: 70, Unique: 45}, comparison.Current)
+		assert.Equal(t, ClickTotals{Total: 30, Unique: 15}, comparison.Previous)
+		assert.Equal(t, 40, comparison.TotalDelta())
+		assert.Equal(t, 30, comparison.UniqueDelta())
+		change, ok := comparison.UniqueChange()
+		assert.True(t, ok)
+		assert.InDelta(t, 2.0, change, 1e-9)
+	})
+
+	t.Run("reports_no_change_ratio_without_previous_clicks", func(t *testing.T) {
+		comparison := ClicksComparison{Current: ClickTotals{Total: 5}}
+
+		_, ok := comparison.TotalChange()
+
+		assert.False(t, ok)
+	})
+}
+
+func TestStatsEntriesKeepRawFields(t *testing.T) {
+	var stats GetCodeStatsResponse
+	err := json.Unmarshal([]byte(`{
+		"referrals": [{"referrer": "https://news.example.com", "total": 6}],
+		"browsers": [{"browser": "Firefox", "total": 4}],
+		"devices": [{"name": "desktop", "total": 4, "share": 0.5}],
+		"locations": [{"countryCode": "US", "total": 4}]
+	}`), &stats)
+
+	require.NoError(t, err)
+	// Fields under other names than the typed ones are not lost
+	assert.Empty(t, stats.Referrals[0].URL)
+	assert.Equal(t, 6, stats.Referrals[0].Total)
+	assert.Equal(t, "https://news.example.com", stats.Referrals[0].Raw["referrer"])
+	assert.Equal(t, "Firefox", stats.Browsers[0].Raw["browser"])
+	assert.Equal(t, "desktop", stats.Devices[0].Name)
+	assert.Equal(t, 0.5, stats.Devices[0].Raw["share"])
+	assert.Equal(t, "US", stats.Locations[0].Raw["countryCode"])
+}
+
+func TestTopStats(t *testing.T) {
+	stats := &GetCodeStatsResponse{
+		Referrals: []ReferralStats{
+			{URL: "https://b.example.com", Total: 5},
+			{URL: "https://c.example.com", Total: 20},
+			{URL: "https://a.example.com", Total: 5},
+		},
+		Locations: []LocationStats{
+			{Country: "US", City: "Seattle", Total: 10, Unique: 8},
+			{Country: "DE", City: "Berlin", Total: 15, Unique: 9},
+			{Country: "US", City: "Austin", Total: 12, Unique: 7},
+			{Country: "FR", City: "Paris", Total: 1, Unique: 1},
+		},
+	}
+
+	t.Run("returns_top_referrers_by_clicks", func(t *testing.T) {
+		assert.Equal(t, []ReferralStats{
+			{URL: "https://c.example.com", Total: 20},
+			{URL: "https://a.example.com", Total: 5},
+		}, stats.TopReferrers(2))
+		assert.Len(t, stats.TopReferrers(0), 3)
+		assert.Equal(t, "https://b.example.com", stats.Referrals[0].URL, "the response is not reordered")
+	})
+
+	t.Run("combines_locations_into_top_countries", func(t *testing.T) {
+		assert.Equal(t, []LocationStats{
+			{Country: "US", Total: 22, Unique: 15},
+			{Country: "DE", Total: 15, Unique: 9},
+		}, stats.TopCountries(2))
+	})
+}
@@ -0,0 +1,218 @@
+package link
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+)
+
+// TagOptions configures tag operations across short codes
+type TagOptions struct {
+	DryRun bool          // Report the changes without updating any short code
+	Batch  *BatchOptions // Concurrency and rate limit for the updates
+}
+
+// TagChange is the change of tags of one short code
+type TagChange struct {
+	ShortCode ShortCodeResponse  // The short code before the change
+	Tags      []string           // The tags after the change
+	Updated   *ShortCodeResponse // The updated short code, nil for a dry run or on failure
+	Err       error              // Why the update failed
+}
+
+func (c TagChange) String() string {
+	return fmt.Sprintf("%s: [%s] -> [%s]", c.ShortCode.Code, strings.Join(c.ShortCode.Tags, ", "), strings.Join(c.Tags, ", "))
+}
+
+// TagReport describes the outcome of a tag operation
+type TagReport struct {
+	DryRun  bool        // Whether the changes were only reported
+	Changes []TagChange // One entry per affected short code, in listing order
+}
+
+// Err summarizes the failed updates, or returns nil if every update succeeded
+func (r *TagReport) Err() error {
+	var failed []TagChange
+	for _, change := range r.Changes {
+		if change.Err != nil {
+			failed = append(failed, change)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: len(failed), Total: len(r.Changes), First: failed[0].Err}
+}
+
+// TagUsage lists the short codes carrying a tag
+type TagUsage struct {
+	Tag   string
+	Count int
+	Codes []ShortCodeResponse
+}
+
+// RenameTag replaces the tag from with to on every short code carrying it.
+// Codes that already carry to keep a single copy.
+func (l *Link) RenameTag(ctx context.Context, from, to string, opts *TagOptions) (*TagReport, error) {
+	return l.MergeTags(ctx, []string{from}, to, opts)
+}
+
+// MergeTags replaces every tag in sources with target on every short code
+// carrying any of them. The error is a *BatchError when some updates failed.
+func (l *Link) MergeTags(ctx context.Context, sources []string, target string, opts *TagOptions) (*TagReport, error) {
+	var v validator
+	if len(sources) == 0 {
+		v.add("sources", "is required")
+	}
+	v.tags("sources", sources)
+	if target == "" {
+		v.add("target", "is required")
+	} else {
+		v.tags("target", []string{target})
+	}
+	if err := v.err(); err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+
+	return l.retag(ctx, sources, func(tags []string) []string {
+		merged := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if slices.Contains(sources, tag) {
+				tag = target
+			}
+			if !slices.Contains(merged, tag) {
+				merged = append(merged, tag)
+			}
+		}
+		return merged
+	}, opts)
+}
+
+// RemoveTag removes tag from every short code carrying it. The error is a
+// *BatchError when some updates failed.
+func (l *Link) RemoveTag(ctx context.Context, tag string, opts *TagOptions) (*TagReport, error) {
+	if tag == "" {
+		err := &ValidationError{Fields: []FieldError{{Field: "tag", Message: "is required"}}}
+		l.emitError(err)
+		return nil, err
+	}
+
+	return l.retag(ctx, []string{tag}, func(tags []string) []string {
+		return slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == tag })
+	}, opts)
+}
+
+// CodesByTag groups every short code by tag, most used tags first
+func (l *Link) CodesByTag(ctx context.Context) ([]TagUsage, error) {
+	byTag := map[string]*TagUsage{}
+	for code, err := range l.ShortCodes(ctx, nil) {
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range code.Tags {
+			usage := byTag[tag]
+			if usage == nil {
+				usage = &TagUsage{Tag: tag}
+				byTag[tag] = usage
+			}
+			usage.Count++
+			usage.Codes = append(usage.Codes, code)
+		}
+	}
+
+	usages := make([]TagUsage, 0, len(byTag))
+	for _, usage := range byTag {
+		usages = append(usages, *usage)
+	}
+	slices.SortFunc(usages, func(a, b TagUsage) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	return usages, nil
+}
+
+// retag rewrites the tags of every short code carrying any of tags
+func (l *Link) retag(ctx context.Context, tags []string, rewrite func([]string) []string, opts *TagOptions) (*TagReport, error) {
+	if opts == nil {
+		opts = &TagOptions{}
+	}
+
+	report := &TagReport{DryRun: opts.DryRun}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		for code, err := range l.ShortCodes(ctx, &ShortCodesOptions{Tags: []string{tag}}) {
+			if err != nil {
+				return nil, err
+			}
+			if seen[code.ID] {
+				continue
+			}
+			seen[code.ID] = true
+
+			if rewritten := rewrite(code.Tags); !slices.Equal(rewritten, code.Tags) {
+				report.Changes = append(report.Changes, TagChange{ShortCode: code, Tags: rewritten})
+			}
+		}
+	}
+
+	if opts.DryRun || len(report.Changes) == 0 {
+		return report, nil
+	}
+
+	indexes := make([]int, len(report.Changes))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	runParallel(ctx, indexes, opts.Batch, func(i int) {
+		change := &report.Changes[i]
+		change.Updated, change.Err = l.patchShortCode(ctx, change.ShortCode.ID, map[string]interface{}{
+			"tags": append([]string{}, change.Tags...),
+		})
+	}, func(i int, err error) {
+		report.Changes[i].Err = err
+	})
+
+	return report, report.Err()
+}
+
+// patchShortCode sends a raw update of a short code. Unlike UpdateShortCode it
+// sends empty values, so tags can be cleared.
+func (l *Link) patchShortCode(ctx context.Context, code string, body map[string]interface{}) (*ShortCodeResponse, error) {
+	targets, err := l.targets(code, "", "")
+	if err != nil {
+		l.emitError(err)
+		return nil, err
+	}
+
+	headers := client.CreateHeaders(l.apiKey)
+	resp, err := l.do(ctx, targets, http.MethodPatch, body, headers)
+	if err != nil {
+		err = fmt.Errorf("failed to update short code: %w", err)
+		l.emitError(err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("failed to update short code: HTTP %d: %s", resp.StatusCode, resp.Status)
+		l.emitError(err)
+		return nil, err
+	}
+
+	var shortCode ShortCodeResponse
+	if err := json.Unmarshal(resp.Body, &shortCode); err != nil {
+		err = fmt.Errorf("failed to unmarshal response: %w", err)
+		l.emitError(err)
+		return nil, err
+	}
+
+	return &shortCode, nil
+}
@@ -0,0 +1,119 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagOperations(t *testing.T) {
+	tagged := []ShortCodeResponse{
+		{ID: "one", Code: "a", Tags: []string{"spring", "promo"}},
+		{ID: "two", Code: "b", Tags: []string{"promo", "summer", "sale"}},
+		{ID: "three", Code: "c", Tags: []string{"sale"}},
+		{ID: "four", Code: "d", Tags: []string{"other"}},
+	}
+	// tagUpdates collects the tags sent with each update by code ID
+	tagUpdates := func(codes *fakeCodes) map[string][]string {
+		updates := map[string][]string{}
+		for id, update := range codes.updates {
+			updates[id] = update.Tags
+		}
+		return updates
+	}
+
+	t.Run("renames_a_tag", func(t *testing.T) {
+		codes := newFakeCodes(tagged...)
+
+		report, err := codes.link().RenameTag(context.Background(), "promo", "campaign", nil)
+
+		require.NoError(t, err)
+		assert.Len(t, report.Changes, 2)
+		assert.Equal(t, map[string][]string{
+			"one": {"spring", "campaign"},
+			"two": {"campaign", "summer", "sale"},
+		}, tagUpdates(codes))
+		assert.Equal(t, []string{"spring", "campaign"}, report.Changes[0].Updated.Tags)
+	})
+
+	t.Run("merges_tags_without_duplicates", func(t *testing.T) {
+		codes := newFakeCodes(tagged...)
+
+		report, err := codes.link().MergeTags(context.Background(), []string{"summer", "sale"}, "promo", &TagOptions{Batch: &BatchOptions{Concurrency: 1}})
+
+		require.NoError(t, err)
+		assert.Len(t, report.Changes, 2)
+		assert.Equal(t, map[string][]string{
+			"two":   {"promo"},
+			"three": {"promo"},
+		}, tagUpdates(codes))
+	})
+
+	t.Run("removes_a_tag_even_when_it_is_the_last", func(t *testing.T) {
+		codes := newFakeCodes(tagged...)
+
+		_, err := codes.link().RemoveTag(context.Background(), "sale", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"two":   {"promo", "summer"},
+			"three": {},
+		}, tagUpdates(codes))
+	})
+
+	t.Run("reports_changes_without_updating_in_a_dry_run", func(t *testing.T) {
+		codes := newFakeCodes(tagged...)
+
+		report, err := codes.link().RenameTag(context.Background(), "sale", "clearance", &TagOptions{DryRun: true})
+
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Empty(t, codes.updates)
+		assert.Equal(t, "b: [promo, summer, sale] -> [promo, summer, clearance]", report.Changes[0].String())
+		assert.Equal(t, "c: [sale] -> [clearance]", report.Changes[1].String())
+	})
+
+	t.Run("reports_failed_updates", func(t *testing.T) {
+		codes := newFakeCodes(append(slices.Clone(tagged), ShortCodeResponse{ID: "broken", Tags: []string{"sale"}})...)
+		codes.fail = func(method, id string, body map[string]interface{}) (*client.Response, error) {
+			if method == http.MethodPatch && id == "broken" {
+				return nil, errors.New("connection reset")
+			}
+			return nil, nil
+		}
+
+		report, err := codes.link().RemoveTag(context.Background(), "sale", nil)
+
+		var batchErr *BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Failed)
+		assert.Equal(t, 3, batchErr.Total)
+		assert.Len(t, codes.updates, 2)
+		assert.Nil(t, report.Changes[2].Updated)
+	})
+
+	t.Run("validates_tags", func(t *testing.T) {
+		_, err := newFakeCodes(tagged...).link().MergeTags(context.Background(), nil, "a,b", nil)
+
+		assert.EqualError(t, err, "invalid request: sources is required; target must not contain commas")
+	})
+
+	t.Run("groups_codes_by_tag", func(t *testing.T) {
+		usages, err := newFakeCodes(tagged...).link().CodesByTag(context.Background())
+
+		require.NoError(t, err)
+		var tags []string
+		for _, usage := range usages {
+			tags = append(tags, usage.Tag)
+			assert.Len(t, usage.Codes, usage.Count)
+		}
+		assert.Equal(t, []string{"promo", "sale", "other", "spring", "summer"}, tags)
+		assert.Equal(t, 2, usages[0].Count)
+	})
+}
@@ -0,0 +1,509 @@
+package link
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is a file format for importing and exporting short codes
+type Format string
+
+const (
+	FormatCSV       Format = "csv"
+	FormatJSON      Format = "json"
+	FormatJSONLines Format = "jsonl" // One JSON object per line, for analytics exports
+)
+
+// Column names used by CSV imports and exports and by JSON import objects
+const (
+	columnLongURL = "long_url"
+	columnDomain  = "domain"
+	columnCode    = "code"
+	columnTitle   = "title"
+	columnTags    = "tags"
+)
+
+// exportStatsChunk is how many codes have their statistics fetched together
+const exportStatsChunk = 50
+
+// ImportRow is one parsed entry of an import file
+type ImportRow struct {
+	Line    int              // Line number in a CSV file, or 1-based position in a JSON array
+	Request ShortCodeRequest // The short code the row describes
+	Errors  []string         // Validation problems, empty when the row is valid
+}
+
+// Valid reports whether the row passed validation
+func (r ImportRow) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// ImportOptions configures ImportShortCodes
+type ImportOptions struct {
+	Format        Format        // Format of the input, defaults to CSV
+	DefaultDomain string        // Domain used for rows without one, defaults to the client's default domain
+	DryRun        bool          // Validate and report without creating anything
+	Batch         *BatchOptions // Concurrency and rate limit for creating codes
+}
+
+// ImportReport describes the outcome of an import
+type ImportReport struct {
+	Rows    []ImportRow  // Every parsed row in input order
+	Valid   int          // Number of rows that passed validation
+	Invalid int          // Number of rows that failed validation
+	Results BatchResults // Creation results for the valid rows, nil for a dry run
+}
+
+// importRecord is the JSON shape of an import entry
+type importRecord struct {
+	LongURL string          `json:"long_url"`
+	Domain  string          `json:"domain"`
+	Code    string          `json:"code"`
+	Title   string          `json:"title"`
+	Tags    json.RawMessage `json:"tags"`
+}
+
+// ParseShortCodes reads short code entries from CSV or JSON and validates each
+// one. CSV input needs a header row naming the long_url, domain, code, title
+// and tags columns; tags within a cell are separated by commas or semicolons.
+// JSON input is an array of objects with the same keys, where tags may be an
+// array or a separated string. Rows without a domain use defaultDomain.
+func ParseShortCodes(r io.Reader, format Format, defaultDomain string) ([]ImportRow, error) {
+	switch format {
+	case FormatCSV, "":
+		return parseCSV(r, defaultDomain)
+	case FormatJSON:
+		return parseJSON(r, defaultDomain)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ImportShortCodes parses, validates and creates short codes from CSV or JSON.
+// Invalid rows are reported and skipped. With DryRun set nothing is created and
+// the report only describes what would happen.
+func (l *Link) ImportShortCodes(ctx context.Context, r io.Reader, opts *ImportOptions) (*ImportReport, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	defaultDomain := opts.DefaultDomain
+	if defaultDomain == "" {
+		defaultDomain = l.defaultDomain
+	}
+	rows, err := ParseShortCodes(r, opts.Format, defaultDomain)
+	if err != nil {
+		err = fmt.Errorf("failed to import short codes: %w", err)
+		l.emitError(err)
+		return nil, err
+	}
+
+	report := &ImportReport{Rows: rows}
+	var requests []ShortCodeRequest
+	for _, row := range rows {
+		if row.Valid() {
+			report.Valid++
+			requests = append(requests, row.Request)
+		} else {
+			report.Invalid++
+		}
+	}
+
+	if opts.DryRun || len(requests) == 0 {
+		return report, nil
+	}
+
+	report.Results, err = l.CreateShortCodes(ctx, requests, opts.Batch)
+	return report, err
+}
+
+// parseCSV reads import rows from CSV with a header row
+func parseCSV(r io.Reader, defaultDomain string) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[columnLongURL]; !ok {
+		return nil, fmt.Errorf("CSV header is missing the %s column", columnLongURL)
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return unescapeFormula(strings.TrimSpace(record[i]))
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		rows = append(rows, newImportRow(line, field(record, columnLongURL), field(record, columnDomain),
+			field(record, columnCode), field(record, columnTitle), splitTags(field(record, columnTags)), defaultDomain))
+	}
+
+	return rows, nil
+}
+
+// parseJSON reads import rows from a JSON array of objects
+func parseJSON(r io.Reader, defaultDomain string) ([]ImportRow, error) {
+	var records []importRecord
+	if err := json.NewDecoder(bufio.NewReader(r)).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	rows := make([]ImportRow, 0, len(records))
+	for i, record := range records {
+		tags, tagsErr := decodeTags(record.Tags)
+		row := newImportRow(i+1, strings.TrimSpace(record.LongURL), strings.TrimSpace(record.Domain),
+			strings.TrimSpace(record.Code), strings.TrimSpace(record.Title), tags, defaultDomain)
+		if tagsErr != nil {
+			row.Errors = append(row.Errors, tagsErr.Error())
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// newImportRow builds and validates an import row
+func newImportRow(line int, longURL, domain, code, title string, tags []string, defaultDomain string) ImportRow {
+	if domain == "" {
+		domain = defaultDomain
+	}
+
+	row := ImportRow{
+		Line:    line,
+		Request: ShortCodeRequest{LongURL: longURL, Domain: domain},
+	}
+	if code != "" || title != "" || len(tags) > 0 {
+		row.Request.Options = &CreateShortCodeOptions{Code: code, Title: title, Tags: tags}
+	}
+
+	var validationErr *ValidationError
+	if errors.As(ValidateShortCode(longURL, domain, row.Request.Options), &validationErr) {
+		for _, field := range validationErr.Fields {
+			row.Errors = append(row.Errors, field.Error())
+		}
+	}
+
+	return row
+}
+
+// decodeTags accepts tags as a JSON array of strings or a separated string
+func decodeTags(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err == nil {
+		return splitTags(joined), nil
+	}
+
+	return nil, errors.New("tags must be an array of strings or a separated string")
+}
+
+// splitTags splits a cell of comma or semicolon separated tags
+func splitTags(cell string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ExportOptions configures ExportShortCodes
+type ExportOptions struct {
+	Format       Format    // Format of the output, defaults to CSV
+	TitleSearch  string    // Only export codes whose title matches
+	Tags         []string  // Only export codes carrying all of these tags
+	IncludeStats bool      // Add click totals from GetCodeStats
+	StatsStart   time.Time // Start of the statistics range, defaults to each code's creation time
+	StatsEnd     time.Time // End of the statistics range, defaults to now
+	Concurrency  int       // Concurrent statistics requests, defaults to 4
+}
+
+// ExportRecord is one exported short code
+type ExportRecord struct {
+	ShortCodeResponse
+	TotalClicks  *int `json:"totalClicks,omitempty"`
+	UniqueClicks *int `json:"uniqueClicks,omitempty"`
+}
+
+// ExportShortCodes writes every short code of the organization to w as CSV or
+// a JSON array, optionally with click totals, and returns how many codes were
+// written
+func (l *Link) ExportShortCodes(ctx context.Context, w io.Writer, opts *ExportOptions) (int, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	var writer exportWriter
+	switch opts.Format {
+	case FormatCSV, "":
+		writer = newCSVExportWriter(w, opts.IncludeStats)
+	case FormatJSON:
+		writer = &jsonExportWriter{w: w}
+	default:
+		err := fmt.Errorf("unsupported format %q", opts.Format)
+		l.emitError(err)
+		return 0, err
+	}
+
+	count := 0
+	var chunk []ExportRecord
+	// flush writes the pending chunk; GetCodeStats reports its own errors
+	flush := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if opts.IncludeStats {
+			if err := l.addExportStats(ctx, chunk, opts); err != nil {
+				return err
+			}
+		}
+		for _, record := range chunk {
+			if err := writer.Write(record); err != nil {
+				err = fmt.Errorf("failed to write export: %w", err)
+				l.emitError(err)
+				return err
+			}
+		}
+		count += len(chunk)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	iterOpts := &ShortCodesOptions{TitleSearch: opts.TitleSearch, Tags: opts.Tags, Prefetch: true}
+	for code, err := range l.ShortCodes(ctx, iterOpts) {
+		if err != nil {
+			return count, err
+		}
+		chunk = append(chunk, ExportRecord{ShortCodeResponse: code})
+		if len(chunk) == exportStatsChunk {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, err
+	}
+	if err := writer.Close(); err != nil {
+		err = fmt.Errorf("failed to write export: %w", err)
+		l.emitError(err)
+		return count, err
+	}
+
+	return count, nil
+}
+
+// addExportStats fills in click totals for a chunk of records concurrently,
+// starting no further requests once ctx is done
+func (l *Link) addExportStats(ctx context.Context, records []ExportRecord, opts *ExportOptions) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	end := opts.StatsEnd
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	errs := make([]error, len(records))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range records {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			record := &records[i]
+			start := opts.StatsStart
+			if start.IsZero() {
+				start, _ = time.Parse(time.RFC3339, record.CreatedAt)
+			}
+
+			stats, err := l.GetCodeStats(ctx, record.Code, start, end)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			record.TotalClicks = &stats.Clicks.Total
+			record.UniqueClicks = &stats.Clicks.Unique
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// exportWriter writes export records in a specific format
+type exportWriter interface {
+	Write(record ExportRecord) error
+	Close() error
+}
+
+// csvExportWriter writes records as CSV with a header row
+type csvExportWriter struct {
+	w            *csv.Writer
+	includeStats bool
+	wroteHeader  bool
+}
+
+func newCSVExportWriter(w io.Writer, includeStats bool) *csvExportWriter {
+	return &csvExportWriter{w: csv.NewWriter(w), includeStats: includeStats}
+}
+
+func (c *csvExportWriter) header() []string {
+	header := []string{"id", columnCode, columnLongURL, columnDomain, columnTitle, columnTags, "created_at"}
+	if c.includeStats {
+		header = append(header, "total_clicks", "unique_clicks")
+	}
+	return header
+}
+
+func (c *csvExportWriter) Write(record ExportRecord) error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(c.header()); err != nil {
+			return err
+		}
+	}
+
+	row := []string{
+		record.ID,
+		record.Code,
+		record.LongURL,
+		record.Domain,
+		record.Title,
+		strings.Join(record.Tags, ";"),
+		record.CreatedAt,
+	}
+	for i, cell := range row {
+		row[i] = escapeFormula(cell)
+	}
+	if c.includeStats {
+		row = append(row, optionalInt(record.TotalClicks), optionalInt(record.UniqueClicks))
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvExportWriter) Close() error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(c.header()); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonExportWriter streams records as a JSON array
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExportWriter) Write(record ExportRecord) error {
+	prefix := ",\n  "
+	if j.count == 0 {
+		prefix = "[\n  "
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	j.count++
+	_, err = io.WriteString(j.w, prefix+string(data))
+	return err
+}
+
+func (j *jsonExportWriter) Close() error {
+	suffix := "\n]\n"
+	if j.count == 0 {
+		suffix = "[]\n"
+	}
+	_, err := io.WriteString(j.w, suffix)
+	return err
+}
+
+// escapeFormula prefixes cells that spreadsheets would evaluate as a formula
+// with a quote so they are shown as text. Cells that already start with
+// quotes before such a character get another one, so unescapeFormula can
+// restore every cell exactly.
+func escapeFormula(cell string) string {
+	if formulaLike(cell) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeFormula removes the quote escapeFormula added
+func unescapeFormula(cell string) string {
+	if strings.HasPrefix(cell, "'") && formulaLike(cell) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// formulaLike reports whether cell starts with a character that makes
+// spreadsheets treat it as a formula, after any leading quotes
+func formulaLike(cell string) bool {
+	rest := strings.TrimLeft(cell, "'")
+	return rest != "" && strings.ContainsRune("=+-@\t\r", rune(rest[0]))
+}
+
+// optionalInt formats an optional integer for CSV
+func optionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
@@ -0,0 +1,234 @@
+package link
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShortCodes(t *testing.T) {
+	t.Run("parses_and_validates_csv_rows", func(t *testing.T) {
+		input := "long_url,domain,code,title,tags\n" +
+			"https://example.com/a,short.link,a,First,\"one, two\"\n" +
+			"https://example.com/b,,,,three;four\n" +
+			"not a url,short.link,,,\n"
+
+		rows, err := ParseShortCodes(strings.NewReader(input), FormatCSV, "default.link")
+
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.Equal(t, 2, rows[0].Line)
+		assert.True(t, rows[0].Valid())
+		assert.Equal(t, ShortCodeRequest{
+			LongURL: "https://example.com/a",
+			Domain:  "short.link",
+			Options: &CreateShortCodeOptions{Code: "a", Title: "First", Tags: []string{"one", "two"}},
+		}, rows[0].Request)
+		assert.Equal(t, "default.link", rows[1].Request.Domain)
+		assert.Equal(t, []string{"three", "four"}, rows[1].Request.Options.Tags)
+		assert.Equal(t, 4, rows[2].Line)
+		assert.Equal(t, []string{"long_url must be an absolute http or https URL"}, rows[2].Errors)
+	})
+
+	t.Run("requires_a_long_url_column", func(t *testing.T) {
+		_, err := ParseShortCodes(strings.NewReader("domain\nshort.link\n"), FormatCSV, "")
+
+		assert.EqualError(t, err, "CSV header is missing the long_url column")
+	})
+
+	t.Run("parses_json_with_array_or_string_tags", func(t *testing.T) {
+		input := `[
+			{"long_url": "https://example.com/a", "domain": "short.link", "tags": ["one", "two"]},
+			{"long_url": "https://example.com/b", "tags": "three,four"},
+			{"domain": "short.link", "tags": 5}
+		]`
+
+		rows, err := ParseShortCodes(strings.NewReader(input), FormatJSON, "")
+
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.True(t, rows[0].Valid())
+		assert.Equal(t, []string{"one", "two"}, rows[0].Request.Options.Tags)
+		assert.Equal(t, []string{"domain is required"}, rows[1].Errors)
+		assert.Equal(t, []string{"three", "four"}, rows[1].Request.Options.Tags)
+		assert.Equal(t, []string{
+			"long_url is required",
+			"tags must be an array of strings or a separated string",
+		}, rows[2].Errors)
+	})
+}
+
+func TestImportShortCodes(t *testing.T) {
+	input := "long_url,domain\n" +
+		"https://example.com/0,short.link\n" +
+		"ftp://example.com/1,short.link\n" +
+		"https://example.com/2,short.link\n"
+
+	t.Run("reports_without_creating_on_dry_run", func(t *testing.T) {
+		codes := newFakeCodes()
+
+		report, err := codes.link().ImportShortCodes(context.Background(), strings.NewReader(input), &ImportOptions{DryRun: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Valid)
+		assert.Equal(t, 1, report.Invalid)
+		assert.Nil(t, report.Results)
+		assert.Empty(t, codes.calls)
+	})
+
+	t.Run("creates_only_the_valid_rows", func(t *testing.T) {
+		codes := newFakeCodes()
+
+		report, err := codes.link().ImportShortCodes(context.Background(), strings.NewReader(input), nil)
+
+		require.NoError(t, err)
+		require.Len(t, report.Results, 2)
+		assert.Equal(t, "https://example.com/0", report.Results[0].ShortCode.LongURL)
+		assert.Equal(t, "https://example.com/2", report.Results[1].ShortCode.LongURL)
+		assert.Equal(t, []string{"POST", "POST"}, codes.calls)
+	})
+
+	t.Run("returns_an_error_for_unreadable_input", func(t *testing.T) {
+		var handled error
+		link := &Link{errorHandler: func(err error) { handled = err }}
+
+		_, err := link.ImportShortCodes(context.Background(), strings.NewReader("{"), &ImportOptions{Format: FormatJSON})
+
+		assert.ErrorContains(t, err, "failed to import short codes: failed to decode JSON")
+		assert.Equal(t, err, handled)
+	})
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		cell    string
+		escaped string
+	}{
+		{"plain", "plain"},
+		{"", ""},
+		{"=SUM(A1)", "'=SUM(A1)"},
+		{"+1", "'+1"},
+		{"-promo", "'-promo"},
+		{"@user", "'@user"},
+		{"\tindented", "'\tindented"},
+		{"\rreturn", "'\rreturn"},
+		{"'quoted", "'quoted"},
+		{"'=already", "''=already"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			assert.Equal(t, tt.escaped, escapeFormula(tt.cell))
+			assert.Equal(t, tt.cell, unescapeFormula(tt.escaped))
+		})
+	}
+}
+
+func TestExportShortCodes(t *testing.T) {
+	exported := []ShortCodeResponse{
+		{ID: "id1", Code: "a", LongURL: "https://example.com/a", Domain: "short.link", Tags: []string{"one", "two"}, CreatedAt: "2025-01-01T00:00:00Z"},
+		{ID: "id2", Code: "b", LongURL: "https://example.com/b", Domain: "short.link", Title: "B"},
+	}
+	clicks := func(string, time.Time) (*GetCodeStatsResponse, error) {
+		return &GetCodeStatsResponse{Clicks: ClicksStats{Total: 10, Unique: 4}}, nil
+	}
+
+	t.Run("writes_csv_with_stats", func(t *testing.T) {
+		codes := newFakeCodes(exported...)
+		codes.stats = clicks
+		var buf bytes.Buffer
+
+		count, err := codes.link().ExportShortCodes(context.Background(), &buf, &ExportOptions{IncludeStats: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, "id,code,long_url,domain,title,tags,created_at,total_clicks,unique_clicks\n"+
+			"id1,a,https://example.com/a,short.link,,one;two,2025-01-01T00:00:00Z,10,4\n"+
+			"id2,b,https://example.com/b,short.link,B,,,10,4\n", buf.String())
+	})
+
+	t.Run("escapes_cells_that_look_like_formulas", func(t *testing.T) {
+		codes := newFakeCodes(ShortCodeResponse{
+			ID: "id1", Code: "a", LongURL: "https://example.com/a", Domain: "short.link",
+			Title: "=HYPERLINK(\"https://evil.test\")", Tags: []string{"+one", "@two"},
+		})
+		var buf bytes.Buffer
+
+		_, err := codes.link().ExportShortCodes(context.Background(), &buf, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "id,code,long_url,domain,title,tags,created_at\n"+
+			"id1,a,https://example.com/a,short.link,\"'=HYPERLINK(\"\"https://evil.test\"\")\",'+one;@two,\n", buf.String())
+	})
+
+	t.Run("reimports_escaped_cells_unchanged", func(t *testing.T) {
+		codes := newFakeCodes(ShortCodeResponse{
+			ID: "id1", Code: "a", LongURL: "https://example.com/a", Domain: "short.link",
+			Title: "-promo", Tags: []string{"-promo", "x"},
+		})
+		var buf bytes.Buffer
+
+		_, err := codes.link().ExportShortCodes(context.Background(), &buf, nil)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), ",'-promo,'-promo;x,")
+
+		rows, err := ParseShortCodes(&buf, FormatCSV, "")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "-promo", rows[0].Request.Options.Title)
+		assert.Equal(t, []string{"-promo", "x"}, rows[0].Request.Options.Tags)
+	})
+
+	t.Run("stops_requesting_stats_once_cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		codes := newFakeCodes(exported...)
+		statsCalls := 0
+		codes.stats = func(id string, start time.Time) (*GetCodeStatsResponse, error) {
+			statsCalls++
+			cancel()
+			return clicks(id, start)
+		}
+		var buf bytes.Buffer
+
+		count, err := codes.link().ExportShortCodes(ctx, &buf, &ExportOptions{IncludeStats: true, Concurrency: 1})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, count)
+		assert.Equal(t, 1, statsCalls)
+	})
+
+	t.Run("writes_a_json_array_that_round_trips", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		count, err := newFakeCodes(exported...).link().ExportShortCodes(context.Background(), &buf, &ExportOptions{Format: FormatJSON})
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		var records []ExportRecord
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+		require.Len(t, records, 2)
+		assert.Equal(t, "a", records[0].Code)
+		assert.Nil(t, records[0].TotalClicks)
+
+		rows, err := ParseShortCodes(&buf, FormatJSON, "")
+		require.NoError(t, err)
+		assert.True(t, rows[0].Valid())
+		assert.Equal(t, "https://example.com/b", rows[1].Request.LongURL)
+	})
+
+	t.Run("writes_an_empty_json_array_when_there_are_no_codes", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		count, err := newFakeCodes().link().ExportShortCodes(context.Background(), &buf, &ExportOptions{Format: FormatJSON})
+
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Equal(t, "[]\n", buf.String())
+	})
+}
@@ -0,0 +1,171 @@
+package link
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxDomainLength is the longest DNS name (RFC 1035). Lengths the Link API
+// does not document are left for the API to check.
+const maxDomainLength = 253
+
+var (
+	// codePattern is the character set allowed in custom short codes
+	codePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// domainLabelPattern matches one DNS label of a domain name
+	domainLabelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+)
+
+// FieldError describes a problem with one field of a request
+type FieldError struct {
+	Field   string // Name of the field as sent to the API, such as long_url
+	Message string // What is wrong, such as "is required"
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError lists every problem found in a request before it was sent
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// validator collects field errors
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, message string) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: message})
+}
+
+// err returns a *ValidationError for the collected problems, or nil
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// ValidateShortCode checks the arguments of CreateShortCode without sending a
+// request. It returns a *ValidationError listing every problem, or nil.
+func ValidateShortCode(longURL, domain string, opts *CreateShortCodeOptions) error {
+	var v validator
+	v.longURL("long_url", longURL, true)
+	v.domain("domain", domain)
+	if opts != nil {
+		if opts.Code != "" {
+			v.code("code", opts.Code)
+		}
+		v.tags("tags", opts.createTags())
+	}
+	return v.err()
+}
+
+// ValidateShortCodeUpdate checks the options of UpdateShortCode without sending
+// a request. It returns a *ValidationError listing every problem, or nil.
+func ValidateShortCodeUpdate(opts *UpdateShortCodeOptions) error {
+	if opts == nil {
+		return nil
+	}
+	var v validator
+	v.longURL("long_url", opts.LongURL, false)
+	if opts.ExpiresAt.IsZero() {
+		v.tags("tags", opts.Tags)
+	} else {
+		v.tags("tags", withExpiry(opts.Tags, opts.ExpiresAt))
+	}
+	return v.err()
+}
+
+// ValidateQRCodeOptions checks the options of CreateQRCode without sending a
+// request. It returns a *ValidationError listing every problem, or nil.
+func ValidateQRCodeOptions(opts *CreateQRCodeOptions) error {
+	if opts == nil {
+		return nil
+	}
+	var v validator
+	if opts.Color != "" {
+		v.color("color", opts.Color)
+	}
+	if opts.BackgroundColor != "" {
+		v.color("backgroundColor", opts.BackgroundColor)
+	}
+	if _, ok := qrSizePixels[opts.Size]; opts.Size != "" && !ok {
+		v.add("size", `must be "small", "medium" or "large"`)
+	}
+	return v.err()
+}
+
+func (v *validator) longURL(field, longURL string, required bool) {
+	if longURL == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(longURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "must be an absolute http or https URL")
+	}
+}
+
+func (v *validator) domain(field, domain string) {
+	if domain == "" {
+		v.add(field, "is required")
+		return
+	}
+	labels := strings.Split(domain, ".")
+	if len(domain) > maxDomainLength || len(labels) < 2 {
+		v.add(field, "must be a domain name such as h4n.link")
+		return
+	}
+	for _, label := range labels {
+		if !domainLabelPattern.MatchString(label) {
+			v.add(field, "must be a domain name such as h4n.link")
+			return
+		}
+	}
+}
+
+func (v *validator) code(field, code string) {
+	if !codePattern.MatchString(code) {
+		v.add(field, "may only contain letters, digits, '-' and '_'")
+	}
+}
+
+func (v *validator) tags(field string, tags []string) {
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		switch {
+		case strings.TrimSpace(tag) == "":
+			v.add(field, "must not contain empty tags")
+		case strings.Contains(tag, ","):
+			// Tags are joined with commas when filtering short codes
+			v.add(field, "must not contain commas")
+		case seen[tag]:
+			v.add(field, "must not contain duplicate tag "+tag)
+		}
+		seen[tag] = true
+	}
+}
+
+func (v *validator) color(field, color string) {
+	if !strings.HasPrefix(color, "#") {
+		v.add(field, "must be a hex color such as #1a2b3c")
+		return
+	}
+	if _, err := parseHexColor(color); err != nil {
+		v.add(field, "must be a hex color such as #1a2b3c")
+	}
+}
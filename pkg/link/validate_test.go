@@ -0,0 +1,95 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateShortCode(t *testing.T) {
+	t.Run("accepts_valid_input", func(t *testing.T) {
+		err := ValidateShortCode("https://example.com/path?q=1", "test.h4n.link", &CreateShortCodeOptions{
+			Code: "spring_sale-2025",
+			Tags: []string{"spring", "sale"},
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("lists_every_problem_field", func(t *testing.T) {
+		err := ValidateShortCode("ftp://example.com", "not a domain", &CreateShortCodeOptions{
+			Code: "has spaces",
+			Tags: []string{"ok", "", "a,b", "ok"},
+		})
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, []FieldError{
+			{Field: "long_url", Message: "must be an absolute http or https URL"},
+			{Field: "domain", Message: "must be a domain name such as h4n.link"},
+			{Field: "code", Message: "may only contain letters, digits, '-' and '_'"},
+			{Field: "tags", Message: "must not contain empty tags"},
+			{Field: "tags", Message: "must not contain commas"},
+			{Field: "tags", Message: "must not contain duplicate tag ok"},
+		}, validationErr.Fields)
+		assert.True(t, strings.HasPrefix(err.Error(), "invalid request: long_url must be an absolute http or https URL; domain must be"))
+	})
+
+	t.Run("leaves_lengths_to_the_api", func(t *testing.T) {
+		tags := make([]string, 30)
+		for i := range tags {
+			tags[i] = fmt.Sprintf("tag-%d-%s", i, strings.Repeat("t", 100))
+		}
+
+		err := ValidateShortCode("https://example.com/"+strings.Repeat("a", 4096), "h4n.link", &CreateShortCodeOptions{
+			Code: strings.Repeat("c", 200),
+			Tags: tags,
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("requires_url_and_domain", func(t *testing.T) {
+		assert.EqualError(t, ValidateShortCode("", "", nil), "invalid request: long_url is required; domain is required")
+	})
+}
+
+func TestValidateShortCodeUpdate(t *testing.T) {
+	assert.NoError(t, ValidateShortCodeUpdate(nil))
+	assert.NoError(t, ValidateShortCodeUpdate(&UpdateShortCodeOptions{Title: "only a title"}))
+	assert.EqualError(t, ValidateShortCodeUpdate(&UpdateShortCodeOptions{LongURL: "example.com"}),
+		"invalid request: long_url must be an absolute http or https URL")
+}
+
+func TestValidateQRCodeOptions(t *testing.T) {
+	assert.NoError(t, ValidateQRCodeOptions(&CreateQRCodeOptions{Color: "#000", BackgroundColor: "#ffffffcc", Size: QRSizeLarge}))
+	assert.EqualError(t, ValidateQRCodeOptions(&CreateQRCodeOptions{Color: "black", BackgroundColor: "#12345", Size: "xl"}),
+		`invalid request: color must be a hex color such as #1a2b3c; backgroundColor must be a hex color such as #1a2b3c; size must be "small", "medium" or "large"`)
+}
+
+func TestValidationBeforeSending(t *testing.T) {
+	var handled error
+	link := &Link{
+		uris:           []string{"https://api.test.com/{organizationId}/codes/"},
+		organizationID: "theOrgId",
+		client:         &FakeHTTPClient{}, // Panics if any request is sent
+		errorHandler:   func(err error) { handled = err },
+	}
+	ctx := context.Background()
+
+	_, err := link.CreateShortCode(ctx, "not a url", "short.link", nil)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, err, handled)
+
+	_, err = link.UpdateShortCode(ctx, "theCode", &UpdateShortCodeOptions{Tags: []string{""}})
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = link.CreateQRCode(ctx, "theCode", &CreateQRCodeOptions{Size: "huge"})
+	assert.ErrorAs(t, err, &validationErr)
+}
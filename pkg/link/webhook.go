@@ -0,0 +1,400 @@
+package link
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+)
+
+const (
+	defaultWebhookTolerance   = 5 * time.Minute
+	defaultWebhookMaxBodySize = 1 << 20
+)
+
+var (
+	// ErrWebhookSignature is returned when a delivery's signature does not verify
+	ErrWebhookSignature = errors.New("webhook signature does not match")
+
+	// ErrWebhookTimestamp is returned when a webhook was signed too long ago
+	// or too far in the future
+	ErrWebhookTimestamp = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// WebhookEventType identifies the kind of a webhook event
+type WebhookEventType string
+
+const (
+	EventLinkClicked WebhookEventType = "link.clicked" // A short code was followed
+	EventCodeCreated WebhookEventType = "code.created" // A short code was created
+	EventCodeUpdated WebhookEventType = "code.updated" // A short code was changed
+	EventCodeDeleted WebhookEventType = "code.deleted" // A short code was deleted
+)
+
+// WebhookEvent is the envelope of every webhook delivery. A failed delivery
+// is retried with the same ID, so handlers should be idempotent.
+type WebhookEvent struct {
+	ID             string           `json:"id"`
+	Type           WebhookEventType `json:"type"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	OrganizationID string           `json:"organizationId,omitempty"`
+	Data           json.RawMessage  `json:"data"`
+}
+
+// ClickEvent is the data of a link.clicked event
+type ClickEvent struct {
+	Event       WebhookEvent `json:"-"`
+	ShortCodeID string       `json:"shortCodeId"`
+	Code        string       `json:"code"`
+	Domain      string       `json:"domain"`
+	LongURL     string       `json:"long_url"`
+	Referrer    string       `json:"referrer,omitempty"`
+	IP          string       `json:"ip,omitempty"`
+	UserAgent   string       `json:"userAgent,omitempty"`
+	Country     string       `json:"country,omitempty"`
+	City        string       `json:"city,omitempty"`
+	Device      string       `json:"device,omitempty"`
+	ClickedAt   time.Time    `json:"clickedAt"`
+}
+
+// CodeCreatedEvent is the data of a code.created event
+type CodeCreatedEvent struct {
+	Event     WebhookEvent      `json:"-"`
+	ShortCode ShortCodeResponse `json:"shortCode"`
+}
+
+// CodeUpdatedEvent is the data of a code.updated event
+type CodeUpdatedEvent struct {
+	Event     WebhookEvent       `json:"-"`
+	ShortCode ShortCodeResponse  `json:"shortCode"`
+	Previous  *ShortCodeResponse `json:"previous,omitempty"` // The short code before the change, when sent
+}
+
+// CodeDeletedEvent is the data of a code.deleted event
+type CodeDeletedEvent struct {
+	Event     WebhookEvent      `json:"-"`
+	ShortCode ShortCodeResponse `json:"shortCode"`
+}
+
+func (e *ClickEvent) setEvent(event WebhookEvent)       { e.Event = event }
+func (e *CodeCreatedEvent) setEvent(event WebhookEvent) { e.Event = event }
+func (e *CodeUpdatedEvent) setEvent(event WebhookEvent) { e.Event = event }
+func (e *CodeDeletedEvent) setEvent(event WebhookEvent) { e.Event = event }
+
+// webhookData is a pointer to a typed event that records its envelope
+type webhookData[T any] interface {
+	*T
+	setEvent(WebhookEvent)
+}
+
+// webhookCallback handles one delivered event
+type webhookCallback func(ctx context.Context, event *WebhookEvent) error
+
+// WebhookOption configures a WebhookHandler
+type WebhookOption func(*WebhookHandler)
+
+// WebhookVerifier authenticates a webhook delivery from its request and raw
+// body, returning an error to reject it
+type WebhookVerifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+// WebhookVerifierFunc adapts a function to a WebhookVerifier
+type WebhookVerifierFunc func(r *http.Request, body []byte) error
+
+// Verify calls f
+func (f WebhookVerifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+// HMACVerifier verifies deliveries signed with a shared secret and a
+// timestamp. The signature header holds the signing time and one or more
+// signatures, as in t=1700000000,v1=<hex>, where each v1 value is the hex
+// HMAC-SHA256 of "<t>.<body>". The sender must be configured to sign this way;
+// SignWebhook produces matching headers.
+type HMACVerifier struct {
+	header    string
+	secrets   [][]byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewHMACVerifier creates a verifier reading signatures from header. Every
+// secret is accepted, so a previous secret can be kept while rotating.
+// Tolerance is how far the signed time may be from now, defaulting to 5
+// minutes when zero; a negative tolerance disables the check.
+func NewHMACVerifier(header string, secrets []string, tolerance time.Duration) (*HMACVerifier, error) {
+	if header == "" {
+		return nil, fmt.Errorf("webhook signature header is required")
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("webhook secret is required")
+	}
+
+	v := &HMACVerifier{header: header, tolerance: tolerance, now: time.Now}
+	if v.tolerance == 0 {
+		v.tolerance = defaultWebhookTolerance
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			return nil, fmt.Errorf("webhook secret is required")
+		}
+		v.secrets = append(v.secrets, []byte(secret))
+	}
+	return v, nil
+}
+
+// WithWebhookMaxBodySize limits the size of a delivery (defaults to 1 MiB)
+func WithWebhookMaxBodySize(n int64) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.maxBodySize = n
+	}
+}
+
+// WithWebhookLogger sets the logger for rejected deliveries and failed callbacks
+func WithWebhookLogger(logger *slog.Logger) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.logger = logger
+	}
+}
+
+// WebhookHandler is an http.Handler that receives Hyphen link webhooks. It
+// authenticates each delivery with its verifier, decodes the event and
+// dispatches it to the registered callbacks.
+//
+// Responses tell Hyphen whether to retry: 401 for a delivery the verifier
+// rejects, 400 for a malformed event, 413 for an oversized body, 500 when a
+// callback returns an error and 204 otherwise, including for event types with
+// no callbacks.
+type WebhookHandler struct {
+	verifier     WebhookVerifier
+	maxBodySize  int64
+	logger       *slog.Logger
+	mu           sync.RWMutex
+	callbacks    map[WebhookEventType][]webhookCallback
+	all          []webhookCallback
+	errorHandler func(error)
+}
+
+// NewWebhookHandler creates a handler accepting the deliveries verifier
+// accepts. The SDK does not assume how Hyphen signs deliveries, so pass a
+// verifier for the scheme your webhook is configured with, such as an
+// HMACVerifier.
+func NewWebhookHandler(verifier WebhookVerifier, options ...WebhookOption) (*WebhookHandler, error) {
+	if verifier == nil {
+		return nil, fmt.Errorf("webhook verifier is required")
+	}
+
+	h := &WebhookHandler{
+		verifier:    verifier,
+		maxBodySize: defaultWebhookMaxBodySize,
+		callbacks:   map[WebhookEventType][]webhookCallback{},
+	}
+	for _, option := range options {
+		option(h)
+	}
+	h.logger = client.ServiceLogger(h.logger, "link")
+
+	return h, nil
+}
+
+// SetErrorHandler sets a function called with every rejected delivery and
+// failed callback
+func (h *WebhookHandler) SetErrorHandler(handler func(error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errorHandler = handler
+}
+
+// OnEvent registers a callback for every event, including types this
+// package does not know. It runs before the typed callbacks.
+func (h *WebhookHandler) OnEvent(fn func(context.Context, *WebhookEvent) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.all = append(h.all, fn)
+}
+
+// OnClick registers a callback for link.clicked events
+func (h *WebhookHandler) OnClick(fn func(context.Context, *ClickEvent) error) {
+	onWebhook(h, EventLinkClicked, fn)
+}
+
+// OnCreated registers a callback for code.created events
+func (h *WebhookHandler) OnCreated(fn func(context.Context, *CodeCreatedEvent) error) {
+	onWebhook(h, EventCodeCreated, fn)
+}
+
+// OnUpdated registers a callback for code.updated events
+func (h *WebhookHandler) OnUpdated(fn func(context.Context, *CodeUpdatedEvent) error) {
+	onWebhook(h, EventCodeUpdated, fn)
+}
+
+// OnDeleted registers a callback for code.deleted events
+func (h *WebhookHandler) OnDeleted(fn func(context.Context, *CodeDeletedEvent) error) {
+	onWebhook(h, EventCodeDeleted, fn)
+}
+
+// onWebhook registers a callback that decodes the event data into T
+func onWebhook[T any, P webhookData[T]](h *WebhookHandler, eventType WebhookEventType, fn func(context.Context, P) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.callbacks[eventType] = append(h.callbacks[eventType], func(ctx context.Context, event *WebhookEvent) error {
+		data := P(new(T))
+		if err := json.Unmarshal(event.Data, data); err != nil {
+			return &webhookDecodeError{err: fmt.Errorf("failed to decode %s event %s: %w", event.Type, event.ID, err)}
+		}
+		data.setEvent(*event)
+		return fn(ctx, data)
+	})
+}
+
+// webhookDecodeError marks a malformed event, which a retry will not fix
+type webhookDecodeError struct {
+	err error
+}
+
+func (e *webhookDecodeError) Error() string { return e.err.Error() }
+func (e *webhookDecodeError) Unwrap() error { return e.err }
+
+// ServeHTTP verifies, decodes and dispatches one webhook delivery
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.reject(w, status, fmt.Errorf("failed to read webhook: %w", err))
+		return
+	}
+
+	if err := h.verifier.Verify(r, body); err != nil {
+		h.reject(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Type == "" {
+		if err == nil {
+			err = errors.New("type is missing")
+		}
+		h.reject(w, http.StatusBadRequest, fmt.Errorf("failed to decode webhook: %w", err))
+		return
+	}
+
+	if err := h.dispatch(r.Context(), &event); err != nil {
+		var decodeErr *webhookDecodeError
+		if errors.As(err, &decodeErr) {
+			h.reject(w, http.StatusBadRequest, err)
+			return
+		}
+		h.reject(w, http.StatusInternalServerError, fmt.Errorf("failed to handle %s event %s: %w", event.Type, event.ID, err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify checks the signature header of a delivery against the secrets and
+// the timestamp tolerance
+func (v *HMACVerifier) Verify(r *http.Request, body []byte) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(r.Header.Get(v.header), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return fmt.Errorf("%w: %s header is missing or malformed", ErrWebhookSignature, v.header)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %q", ErrWebhookSignature, timestamp)
+	}
+	if v.tolerance >= 0 {
+		if age := v.now().Sub(time.Unix(unix, 0)).Abs(); age > v.tolerance {
+			return fmt.Errorf("%w: signed %s away from now", ErrWebhookTimestamp, age.Truncate(time.Second))
+		}
+	}
+
+	for _, secret := range v.secrets {
+		expected := webhookMAC(secret, timestamp, body)
+		for _, signature := range signatures {
+			if got, err := hex.DecodeString(signature); err == nil && hmac.Equal(got, expected) {
+				return nil
+			}
+		}
+	}
+	return ErrWebhookSignature
+}
+
+// dispatch runs the callbacks for an event in registration order, stopping
+// at the first error
+func (h *WebhookHandler) dispatch(ctx context.Context, event *WebhookEvent) error {
+	h.mu.RLock()
+	callbacks := append(append([]webhookCallback{}, h.all...), h.callbacks[event.Type]...)
+	h.mu.RUnlock()
+
+	for _, callback := range callbacks {
+		if err := callback(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reject logs a failed delivery, reports it to the error handler and writes
+// the status
+func (h *WebhookHandler) reject(w http.ResponseWriter, status int, err error) {
+	h.logger.Warn("webhook rejected", slog.Int(client.LogKeyStatus, status), slog.Any(client.LogKeyError, err))
+
+	h.mu.RLock()
+	handler := h.errorHandler
+	h.mu.RUnlock()
+	if handler != nil {
+		handler(err)
+	}
+
+	http.Error(w, http.StatusText(status), status)
+}
+
+// SignWebhook returns the HMACVerifier signature header value for a webhook
+// body sent at timestamp, for example to test a WebhookHandler
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(webhookMAC([]byte(secret), t, body))
+}
+
+// webhookMAC computes the HMAC-SHA256 of "<timestamp>.<body>"
+func webhookMAC(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
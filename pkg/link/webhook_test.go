@@ -0,0 +1,213 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	click := `{"id":"evt_1","type":"link.clicked","createdAt":"2025-07-01T12:00:00Z","data":{"shortCodeId":"code_1","code":"promo","domain":"h4n.link","country":"US","clickedAt":"2025-07-01T11:59:58Z"}}`
+	newVerifier := func(t *testing.T, secrets []string, tolerance time.Duration) *HMACVerifier {
+		verifier, err := NewHMACVerifier("X-Signature", secrets, tolerance)
+		require.NoError(t, err)
+		verifier.now = func() time.Time { return now }
+		return verifier
+	}
+	newHandler := func(t *testing.T, options ...WebhookOption) *WebhookHandler {
+		handler, err := NewWebhookHandler(newVerifier(t, []string{"theSecret"}, 0), options...)
+		require.NoError(t, err)
+		return handler
+	}
+	deliver := func(handler http.Handler, signature, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/hyphen", strings.NewReader(body))
+		r.Header.Set("X-Signature", signature)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("dispatches_typed_events", func(t *testing.T) {
+		handler := newHandler(t)
+		var clicks []*ClickEvent
+		var types []WebhookEventType
+		handler.OnEvent(func(ctx context.Context, event *WebhookEvent) error {
+			types = append(types, event.Type)
+			return nil
+		})
+		handler.OnClick(func(ctx context.Context, event *ClickEvent) error {
+			clicks = append(clicks, event)
+			return nil
+		})
+		var updated *CodeUpdatedEvent
+		handler.OnUpdated(func(ctx context.Context, event *CodeUpdatedEvent) error {
+			updated = event
+			return nil
+		})
+
+		w := deliver(handler, SignWebhook("theSecret", now, []byte(click)), click)
+		update := `{"id":"evt_2","type":"code.updated","data":{"shortCode":{"id":"code_1","code":"promo","tags":["b"]},"previous":{"id":"code_1","code":"promo","tags":["a"]}}}`
+		deliver(handler, SignWebhook("theSecret", now, []byte(update)), update)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, []WebhookEventType{EventLinkClicked, EventCodeUpdated}, types)
+		require.Len(t, clicks, 1)
+		assert.Equal(t, "evt_1", clicks[0].Event.ID)
+		assert.Equal(t, "promo", clicks[0].Code)
+		assert.Equal(t, "US", clicks[0].Country)
+		assert.Equal(t, time.Date(2025, 7, 1, 11, 59, 58, 0, time.UTC), clicks[0].ClickedAt)
+		require.NotNil(t, updated)
+		assert.Equal(t, []string{"b"}, updated.ShortCode.Tags)
+		assert.Equal(t, []string{"a"}, updated.Previous.Tags)
+	})
+
+	t.Run("acknowledges_events_without_callbacks", func(t *testing.T) {
+		body := `{"id":"evt_3","type":"domain.verified","data":{}}`
+
+		w := deliver(newHandler(t), SignWebhook("theSecret", now, []byte(body)), body)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("rejects_invalid_signatures", func(t *testing.T) {
+		handler := newHandler(t)
+		var errs []error
+		handler.SetErrorHandler(func(err error) { errs = append(errs, err) })
+		called := false
+		handler.OnClick(func(ctx context.Context, event *ClickEvent) error {
+			called = true
+			return nil
+		})
+
+		for _, signature := range []string{
+			"",
+			SignWebhook("otherSecret", now, []byte(click)),
+			SignWebhook("theSecret", now, []byte(click+" ")),
+			"t=notanumber,v1=00",
+		} {
+			w := deliver(handler, signature, click)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, signature)
+		}
+
+		assert.False(t, called)
+		require.Len(t, errs, 4)
+		for _, err := range errs {
+			assert.ErrorIs(t, err, ErrWebhookSignature)
+		}
+	})
+
+	t.Run("rejects_timestamps_outside_the_tolerance", func(t *testing.T) {
+		handler, err := NewWebhookHandler(newVerifier(t, []string{"theSecret"}, time.Minute))
+		require.NoError(t, err)
+		var errs []error
+		handler.SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+		old := deliver(handler, SignWebhook("theSecret", now.Add(-2*time.Minute), []byte(click)), click)
+		recent := deliver(handler, SignWebhook("theSecret", now.Add(-30*time.Second), []byte(click)), click)
+
+		assert.Equal(t, http.StatusUnauthorized, old.Code)
+		assert.Equal(t, http.StatusNoContent, recent.Code)
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrWebhookTimestamp)
+	})
+
+	t.Run("accepts_an_additional_secret_while_rotating", func(t *testing.T) {
+		handler, err := NewWebhookHandler(newVerifier(t, []string{"theSecret", "oldSecret"}, 0))
+		require.NoError(t, err)
+
+		w := deliver(handler, SignWebhook("oldSecret", now, []byte(click)), click)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("returns_500_when_a_callback_fails_so_the_event_is_retried", func(t *testing.T) {
+		handler := newHandler(t)
+		var handled error
+		handler.SetErrorHandler(func(err error) { handled = err })
+		handler.OnClick(func(ctx context.Context, event *ClickEvent) error {
+			return errors.New("database unavailable")
+		})
+
+		w := deliver(handler, SignWebhook("theSecret", now, []byte(click)), click)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.EqualError(t, handled, "failed to handle link.clicked event evt_1: database unavailable")
+	})
+
+	t.Run("rejects_malformed_events", func(t *testing.T) {
+		handler := newHandler(t)
+		handler.OnClick(func(ctx context.Context, event *ClickEvent) error { return nil })
+
+		for _, body := range []string{
+			`not json`,
+			`{"id":"evt_4","data":{}}`,
+			`{"id":"evt_5","type":"link.clicked","data":{"clickedAt":"yesterday"}}`,
+		} {
+			w := deliver(handler, SignWebhook("theSecret", now, []byte(body)), body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+
+	t.Run("rejects_other_methods_and_large_bodies", func(t *testing.T) {
+		handler := newHandler(t, WithWebhookMaxBodySize(16))
+
+		get := httptest.NewRecorder()
+		handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/webhooks/hyphen", nil))
+		large := deliver(handler, SignWebhook("theSecret", now, []byte(click)), click)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, get.Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, large.Code)
+	})
+
+	t.Run("rejects_unreadable_bodies_as_bad_requests", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/hyphen", iotest.ErrReader(errors.New("connection reset")))
+		w := httptest.NewRecorder()
+
+		newHandler(t).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("uses_a_custom_verifier", func(t *testing.T) {
+		handler, err := NewWebhookHandler(WebhookVerifierFunc(func(r *http.Request, body []byte) error {
+			if r.Header.Get("Authorization") != "Bearer theToken" {
+				return ErrWebhookSignature
+			}
+			return nil
+		}))
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/hyphen", strings.NewReader(click))
+		r.Header.Set("Authorization", "Bearer theToken")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, deliver(handler, "", click).Code)
+	})
+
+	t.Run("requires_a_verifier", func(t *testing.T) {
+		_, err := NewWebhookHandler(nil)
+
+		assert.EqualError(t, err, "webhook verifier is required")
+	})
+}
+
+func TestNewHMACVerifier(t *testing.T) {
+	_, noHeader := NewHMACVerifier("", []string{"theSecret"}, 0)
+	_, noSecret := NewHMACVerifier("X-Signature", nil, 0)
+	_, emptySecret := NewHMACVerifier("X-Signature", []string{"theSecret", ""}, 0)
+
+	assert.EqualError(t, noHeader, "webhook signature header is required")
+	assert.EqualError(t, noSecret, "webhook secret is required")
+	assert.EqualError(t, emptySecret, "webhook secret is required")
+}
@@ -0,0 +1,242 @@
+package netinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+)
+
+// DefaultChunkSize is the number of IPs sent per bulk request when none is
+// configured
+const DefaultChunkSize = 100
+
+const (
+	defaultBulkConcurrency = 4
+	defaultBulkRetries     = 2
+	defaultBulkRetryDelay  = 500 * time.Millisecond
+)
+
+// BulkOptions configures StreamIPInfos and IPInfos
+type BulkOptions struct {
+	ChunkSize   int           // IPs per request, defaults to DefaultChunkSize
+	Concurrency int           // Requests in flight at once, defaults to 4
+	Retries     int           // Extra attempts for a failed request, defaults to 2; negative disables retries
+	RetryDelay  time.Duration // Wait before the first retry, doubled for each further one; defaults to 500ms
+}
+
+// BulkResult is the outcome for one distinct IP of a bulk lookup
+type BulkResult struct {
+	IPInfoResult
+	RequestErr error // Why the request holding the IP failed after all retries
+}
+
+// Err returns why the IP has no information, or nil if the lookup succeeded
+func (r BulkResult) Err() error {
+	if r.RequestErr != nil {
+		return r.RequestErr
+	}
+	return r.IPInfoResult.Err()
+}
+
+// statusError is an unexpected HTTP status from the service
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.status)
+}
+
+// StreamIPInfos looks up many IPs and sends one result per distinct IP on
+// the returned channel, which is closed once every IP was reported.
+// Duplicate and blank entries are dropped, the rest are split into chunks
+// that are looked up concurrently, and failed chunks are retried on network
+// errors, 429 and 5xx responses. Results arrive chunk by chunk as requests
+// complete, not in input order.
+//
+// The channel must be drained. When ctx is done, IPs that were not looked up
+// are reported with the context's error.
+func (n *NetInfo) StreamIPInfos(ctx context.Context, ips []string, opts *BulkOptions) <-chan BulkResult {
+	opts = bulkDefaults(opts)
+	chunks := chunkIPs(ips, opts.ChunkSize)
+	results := make(chan BulkResult, opts.ChunkSize)
+
+	go func() {
+		defer close(results)
+
+		work := make(chan []string)
+		var wg sync.WaitGroup
+		for range min(opts.Concurrency, len(chunks)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for chunk := range work {
+					for _, result := range n.lookupChunk(ctx, chunk, opts) {
+						results <- result
+					}
+				}
+			}()
+		}
+
+		sent := 0
+	feed:
+		for _, chunk := range chunks {
+			select {
+			case work <- chunk:
+				sent++
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(work)
+		wg.Wait()
+
+		for _, chunk := range chunks[sent:] {
+			for _, ip := range chunk {
+				results <- BulkResult{IPInfoResult: IPInfoResult{IP: ip}, RequestErr: ctx.Err()}
+			}
+		}
+	}()
+
+	return results
+}
+
+// IPInfos returns an iterator over the results of a bulk lookup. It behaves
+// like StreamIPInfos; breaking out of the loop cancels the outstanding
+// requests.
+//
+//	for result := range n.IPInfos(ctx, ips, nil) {
+//		if err := result.Err(); err != nil {
+//			log.Printf("%s: %v", result.IP, err)
+//			continue
+//		}
+//		geo[result.IP] = result.Info.Location
+//	}
+func (n *NetInfo) IPInfos(ctx context.Context, ips []string, opts *BulkOptions) iter.Seq[BulkResult] {
+	return func(yield func(BulkResult) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := n.StreamIPInfos(ctx, ips, opts)
+		for result := range results {
+			if !yield(result) {
+				cancel()
+				go func() {
+					for range results {
+					}
+				}()
+				return
+			}
+		}
+	}
+}
+
+// lookupChunk looks up one chunk, retrying failures that may be transient
+func (n *NetInfo) lookupChunk(ctx context.Context, chunk []string, opts *BulkOptions) []BulkResult {
+	delay := opts.RetryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		var infos IPInfoResults
+		infos, err = n.lookupIPInfos(ctx, chunk)
+		if err == nil {
+			results := make([]BulkResult, len(infos))
+			for i, info := range infos {
+				results[i] = BulkResult{IPInfoResult: info}
+			}
+			return results
+		}
+		if attempt >= opts.Retries || !retryable(ctx, err) {
+			break
+		}
+
+		n.log().Warn("retrying ip info chunk",
+			slog.Int("ips", len(chunk)),
+			slog.Int("attempt", attempt+1),
+			slog.Any(client.LogKeyError, err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		delay *= 2
+	}
+
+	n.emitError(err)
+	results := make([]BulkResult, len(chunk))
+	for i, ip := range chunk {
+		results[i] = BulkResult{IPInfoResult: IPInfoResult{IP: ip}, RequestErr: err}
+	}
+	return results
+}
+
+// retryable reports whether a failed request may succeed when sent again:
+// after a network error, a truncated response, 429 or a server error. Other
+// failures, such as a response that cannot be decoded, would repeat.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests || status.code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// bulkDefaults returns a copy of opts with defaults filled in
+func bulkDefaults(opts *BulkOptions) *BulkOptions {
+	o := BulkOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultBulkConcurrency
+	}
+	if o.Retries == 0 {
+		o.Retries = defaultBulkRetries
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = defaultBulkRetryDelay
+	}
+	return &o
+}
+
+// chunkIPs drops blank and repeated IPs, keeping the first occurrence, and
+// splits the rest into chunks of at most size
+func chunkIPs(ips []string, size int) [][]string {
+	seen := make(map[string]bool, len(ips))
+	var chunks [][]string
+	var chunk []string
+	for _, ip := range ips {
+		ip = strings.TrimSpace(ip)
+		if ip == "" || seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		chunk = append(chunk, ip)
+		if len(chunk) == size {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,202 @@
+package netinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc answers requests in process, so tests start no connection
+// goroutines
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// bulkClient answers bulk lookups with the status returned by status for
+// each request, echoing the requested IPs on success
+func bulkClient(t *testing.T, status func(attempt int) int) (*NetInfo, func() int) {
+	var mu sync.Mutex
+	attempts := 0
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var ips []string
+		json.NewDecoder(r.Body).Decode(&ips)
+		mu.Lock()
+		attempts++
+		code := status(attempts)
+		mu.Unlock()
+
+		body := `{"errorMessage":"failed"}`
+		if code == http.StatusOK {
+			data := make([]IPInfo, len(ips))
+			for i, ip := range ips {
+				data[i] = IPInfo{IP: ip, Type: "ipv4"}
+			}
+			encoded, _ := json.Marshal(map[string][]IPInfo{"data": data})
+			body = string(encoded)
+		}
+		return &http.Response{
+			StatusCode: code,
+			Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+
+	n, err := New(WithAPIKey("theKey"), WithBaseURI("https://net.test"), WithHTTPClient(&http.Client{Transport: transport}))
+	require.NoError(t, err)
+	return n, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+}
+
+// ips returns count distinct addresses
+func ips(count int) []string {
+	addresses := make([]string, count)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+	}
+	return addresses
+}
+
+func TestChunkIPs(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		want  []int
+	}{
+		{"no_ips", 0, nil},
+		{"one_ip", 1, []int{1}},
+		{"exactly_one_chunk", DefaultChunkSize, []int{DefaultChunkSize}},
+		{"one_past_a_chunk", DefaultChunkSize + 1, []int{DefaultChunkSize, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			for _, chunk := range chunkIPs(ips(tt.count), DefaultChunkSize) {
+				sizes = append(sizes, len(chunk))
+			}
+
+			assert.Equal(t, tt.want, sizes)
+		})
+	}
+
+	t.Run("drops_blank_and_repeated_ips", func(t *testing.T) {
+		chunks := chunkIPs([]string{"8.8.8.8", " 8.8.8.8 ", "", "  ", "1.1.1.1", "8.8.8.8"}, 10)
+
+		assert.Equal(t, [][]string{{"8.8.8.8", "1.1.1.1"}}, chunks)
+	})
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network_error", fmt.Errorf("request failed: %w", &net.OpError{Op: "read", Err: errors.New("connection reset")}), true},
+		{"truncated_response", fmt.Errorf("failed to unmarshal response: %w", io.ErrUnexpectedEOF), true},
+		{"too_many_requests", &statusError{code: http.StatusTooManyRequests}, true},
+		{"server_error", &statusError{code: http.StatusBadGateway}, true},
+		{"client_error", &statusError{code: http.StatusBadRequest}, false},
+		{"invalid_response", fmt.Errorf("failed to unmarshal response: %w", &json.SyntaxError{}), false},
+		{"result_count_mismatch", errors.New("failed to fetch ip infos: got 1 results for 2 IPs"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, retryable(context.Background(), tt.err))
+		})
+	}
+
+	t.Run("never_after_cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.False(t, retryable(ctx, &statusError{code: http.StatusBadGateway}))
+	})
+}
+
+func TestIPInfos(t *testing.T) {
+	fast := &BulkOptions{RetryDelay: time.Millisecond}
+
+	t.Run("reports_each_distinct_ip_once", func(t *testing.T) {
+		n, attempts := bulkClient(t, func(int) int { return http.StatusOK })
+
+		var got []string
+		for result := range n.IPInfos(context.Background(), []string{"8.8.8.8", "1.1.1.1", "8.8.8.8"}, fast) {
+			require.NoError(t, result.Err())
+			got = append(got, result.IP)
+		}
+
+		assert.ElementsMatch(t, []string{"8.8.8.8", "1.1.1.1"}, got)
+		assert.Equal(t, 1, attempts())
+	})
+
+	t.Run("retries_after_a_server_error", func(t *testing.T) {
+		n, attempts := bulkClient(t, func(attempt int) int {
+			if attempt == 1 {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusOK
+		})
+
+		var results []BulkResult
+		for result := range n.IPInfos(context.Background(), []string{"8.8.8.8"}, fast) {
+			results = append(results, result)
+		}
+
+		require.Len(t, results, 1)
+		assert.NoError(t, results[0].Err())
+		assert.Equal(t, "8.8.8.8", results[0].Info.IP)
+		assert.Equal(t, 2, attempts())
+	})
+
+	t.Run("does_not_retry_a_client_error", func(t *testing.T) {
+		n, attempts := bulkClient(t, func(int) int { return http.StatusBadRequest })
+
+		var results []BulkResult
+		for result := range n.IPInfos(context.Background(), []string{"8.8.8.8", "1.1.1.1"}, fast) {
+			results = append(results, result)
+		}
+
+		require.Len(t, results, 2)
+		for _, result := range results {
+			assert.EqualError(t, result.Err(), "failed to fetch ip infos: HTTP 400: 400 Bad Request")
+		}
+		assert.Equal(t, 1, attempts())
+	})
+
+	t.Run("stops_every_goroutine_after_an_early_break", func(t *testing.T) {
+		n, _ := bulkClient(t, func(int) int { return http.StatusOK })
+		before := runtime.NumGoroutine()
+
+		for range n.IPInfos(context.Background(), ips(10*DefaultChunkSize), &BulkOptions{Concurrency: 2}) {
+			break
+		}
+
+		// Polled inline, as assert.Eventually runs its condition on a goroutine
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+	})
+}
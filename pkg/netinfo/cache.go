@@ -0,0 +1,343 @@
+package netinfo
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheSize        = 10000
+	defaultCacheTTL         = time.Hour
+	defaultCacheNegativeTTL = 5 * time.Minute
+)
+
+// CacheOptions configures the lookup cache enabled by WithCache
+type CacheOptions struct {
+	Size        int           // Maximum number of IPs kept, defaults to 10000
+	TTL         time.Duration // How long information is kept, defaults to 1 hour
+	NegativeTTL time.Duration // How long IPInfoError results are kept, defaults to 5 minutes; negative disables negative caching
+}
+
+// CacheStats counts the activity of the lookup cache
+type CacheStats struct {
+	Hits         uint64 // Lookups answered from the cache, including negative entries
+	NegativeHits uint64 // Hits on cached IPInfoError results
+	Misses       uint64 // Lookups sent to the service
+	Coalesced    uint64 // Lookups that waited for the same IP already in flight
+	Evictions    uint64 // Entries dropped to stay within Size
+	Entries      int    // IPs currently cached, including expired ones not yet dropped
+}
+
+// HitRate returns the share of lookups answered from the cache or by a
+// lookup already in flight
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Coalesced + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.Coalesced) / float64(total)
+}
+
+// cacheEntry is a cached result and when it stops being served
+type cacheEntry struct {
+	addr    netip.Addr
+	result  IPInfoResult
+	expires time.Time
+}
+
+// flight is a lookup in progress that other callers can wait for
+type flight struct {
+	done   chan struct{}
+	result IPInfoResult
+	err    error
+}
+
+// wait blocks until the lookup finishes or ctx is done
+func (f *flight) wait(ctx context.Context) (IPInfoResult, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return IPInfoResult{}, ctx.Err()
+	}
+}
+
+// cache is a size bounded LRU of lookup results with expiry, which also
+// de-duplicates concurrent lookups of the same IP
+type cache struct {
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[netip.Addr]*list.Element
+	order   *list.List // Most recently used at the front
+	flights map[netip.Addr]*flight
+	stats   CacheStats
+}
+
+func newCache(opts CacheOptions) *cache {
+	c := &cache{
+		size:        opts.Size,
+		ttl:         opts.TTL,
+		negativeTTL: opts.NegativeTTL,
+		now:         time.Now,
+		entries:     map[netip.Addr]*list.Element{},
+		order:       list.New(),
+		flights:     map[netip.Addr]*flight{},
+	}
+	if c.size <= 0 {
+		c.size = defaultCacheSize
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultCacheTTL
+	}
+	if c.negativeTTL == 0 {
+		c.negativeTTL = defaultCacheNegativeTTL
+	}
+	return c
+}
+
+// cacheKey normalizes an IP for use as a cache key, so that IPv4-mapped IPv6
+// addresses and differently written IPv6 addresses share an entry
+func cacheKey(ip string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// begin returns the cached result for addr if there is one. Otherwise it
+// returns the flight for addr, and leader is true when the caller started it
+// and must call finish.
+func (c *cache) begin(addr netip.Addr) (result IPInfoResult, cached bool, f *flight, leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[addr]; ok {
+		entry := element.Value.(*cacheEntry)
+		if c.now().Before(entry.expires) {
+			c.order.MoveToFront(element)
+			c.stats.Hits++
+			if entry.result.Error != nil {
+				c.stats.NegativeHits++
+			}
+			return entry.result, true, nil, false
+		}
+		c.order.Remove(element)
+		delete(c.entries, addr)
+	}
+
+	if f, ok := c.flights[addr]; ok {
+		c.stats.Coalesced++
+		return IPInfoResult{}, false, f, false
+	}
+
+	f = &flight{done: make(chan struct{})}
+	c.flights[addr] = f
+	c.stats.Misses++
+	return IPInfoResult{}, false, f, true
+}
+
+// finish completes the flight for addr, storing a successful result
+func (c *cache) finish(addr netip.Addr, f *flight, result IPInfoResult, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.flights, addr)
+	f.result, f.err = result, err
+	close(f.done)
+
+	if err == nil {
+		c.store(addr, result)
+	}
+}
+
+// store adds or replaces the entry for addr, evicting the least recently used
+// entries beyond the size. Callers must hold c.mu.
+func (c *cache) store(addr netip.Addr, result IPInfoResult) {
+	ttl := c.ttl
+	if result.Error != nil {
+		if c.negativeTTL < 0 {
+			return
+		}
+		ttl = c.negativeTTL
+	}
+
+	entry := &cacheEntry{addr: addr, result: result, expires: c.now().Add(ttl)}
+	if element, ok := c.entries[addr]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[addr] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).addr)
+		c.stats.Evictions++
+	}
+}
+
+// snapshot returns the statistics
+func (c *cache) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+// purge drops every entry, leaving lookups in flight alone
+func (c *cache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[netip.Addr]*list.Element{}
+	c.order.Init()
+}
+
+// CacheStats returns the statistics of the lookup cache, or zero statistics
+// when caching is not enabled
+func (n *NetInfo) CacheStats() CacheStats {
+	if n.cache == nil {
+		return CacheStats{}
+	}
+	return n.cache.snapshot()
+}
+
+// PurgeCache drops every cached lookup
+func (n *NetInfo) PurgeCache() {
+	if n.cache != nil {
+		n.cache.purge()
+	}
+}
+
+// lookupIPInfo looks up one IP through the cache. Results with an
+// IPInfoError are returned without an error so they can be cached.
+func (n *NetInfo) lookupIPInfo(ctx context.Context, ip string) (IPInfoResult, error) {
+	addr, ok := cacheKey(ip)
+	if n.cache == nil || !ok {
+		return n.fetchIPInfo(ctx, ip)
+	}
+
+	for {
+		result, cached, f, leader := n.cache.begin(addr)
+		if cached {
+			return result, nil
+		}
+		if leader {
+			result, err := n.fetchIPInfo(ctx, addr.String())
+			n.cache.finish(addr, f, result, err)
+			return result, err
+		}
+
+		result, err := f.wait(ctx)
+		if err != nil && ctx.Err() == nil && isContextError(err) {
+			// The leader gave up, not the service; look the IP up again
+			continue
+		}
+		return result, err
+	}
+}
+
+// lookupIPInfos looks up several IPs through the cache, sending the IPs that
+// are neither cached nor in flight in one request. Results are in the order
+// of ips.
+func (n *NetInfo) lookupIPInfos(ctx context.Context, ips []string) (IPInfoResults, error) {
+	if n.cache == nil {
+		return n.fetchIPInfos(ctx, ips)
+	}
+
+	type pending struct {
+		index int // Position in ips
+		pos   int // Position in fetch, for leaders
+		addr  netip.Addr
+		f     *flight
+	}
+
+	results := make(IPInfoResults, len(ips))
+	var fetch []string
+	var fetchIndexes []int
+	var leaders, followers []pending
+	for i, ip := range ips {
+		addr, ok := cacheKey(ip)
+		if !ok {
+			fetch = append(fetch, ip)
+			fetchIndexes = append(fetchIndexes, i)
+			continue
+		}
+
+		result, cached, f, leader := n.cache.begin(addr)
+		switch {
+		case cached:
+			results[i] = result
+		case leader:
+			leaders = append(leaders, pending{index: i, pos: len(fetch), addr: addr, f: f})
+			fetch = append(fetch, addr.String())
+			fetchIndexes = append(fetchIndexes, i)
+		default:
+			followers = append(followers, pending{index: i, addr: addr, f: f})
+		}
+	}
+
+	if len(fetch) > 0 {
+		fetched, err := n.fetchIPInfos(ctx, fetch)
+		for _, p := range leaders {
+			var result IPInfoResult
+			if err == nil {
+				result = fetched[p.pos]
+			}
+			n.cache.finish(p.addr, p.f, result, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range fetchIndexes {
+			results[i] = fetched[j]
+		}
+	}
+
+	var retry []string
+	var retryIndexes []int
+	for _, p := range followers {
+		result, err := p.f.wait(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			// The lookup we waited for failed; send the IP ourselves
+			retry = append(retry, ips[p.index])
+			retryIndexes = append(retryIndexes, p.index)
+			continue
+		}
+		results[p.index] = result
+	}
+	if len(retry) > 0 {
+		fetched, err := n.fetchIPInfos(ctx, retry)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range retryIndexes {
+			results[i] = fetched[j]
+		}
+	}
+
+	for i := range results {
+		results[i].IP = ips[i]
+	}
+	return results, nil
+}
+
+// isContextError reports whether err comes from a cancelled or expired context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
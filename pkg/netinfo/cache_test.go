@@ -0,0 +1,112 @@
+package netinfo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	newTestCache := func(opts CacheOptions) *cache {
+		c := newCache(opts)
+		c.now = func() time.Time { return now }
+		return c
+	}
+	put := func(c *cache, ip string, result IPInfoResult) {
+		addr, _ := cacheKey(ip)
+		_, _, f, leader := c.begin(addr)
+		assert.True(t, leader)
+		c.finish(addr, f, result, nil)
+	}
+	cached := func(c *cache, ip string) bool {
+		addr, _ := cacheKey(ip)
+		_, ok, f, leader := c.begin(addr)
+		if leader {
+			c.finish(addr, f, IPInfoResult{}, assert.AnError)
+		}
+		return ok
+	}
+
+	t.Run("normalizes_keys", func(t *testing.T) {
+		mapped, _ := cacheKey("::ffff:10.0.0.1")
+		plain, _ := cacheKey("10.0.0.1")
+		long, _ := cacheKey("2001:0db8:0000:0000:0000:0000:0000:0001")
+		short, _ := cacheKey("2001:db8::1")
+		_, ok := cacheKey("not-an-ip")
+
+		assert.Equal(t, plain, mapped)
+		assert.Equal(t, short, long)
+		assert.False(t, ok)
+	})
+
+	t.Run("evicts_the_least_recently_used_entry", func(t *testing.T) {
+		c := newTestCache(CacheOptions{Size: 2})
+		put(c, "10.0.0.1", IPInfoResult{Info: &IPInfo{IP: "10.0.0.1"}})
+		put(c, "10.0.0.2", IPInfoResult{Info: &IPInfo{IP: "10.0.0.2"}})
+		assert.True(t, cached(c, "10.0.0.1"))
+
+		put(c, "10.0.0.3", IPInfoResult{Info: &IPInfo{IP: "10.0.0.3"}})
+
+		assert.True(t, cached(c, "10.0.0.1"))
+		assert.False(t, cached(c, "10.0.0.2"))
+		assert.Equal(t, uint64(1), c.snapshot().Evictions)
+	})
+
+	t.Run("expires_entries", func(t *testing.T) {
+		c := newTestCache(CacheOptions{TTL: time.Hour, NegativeTTL: time.Minute})
+		put(c, "10.0.0.1", IPInfoResult{Info: &IPInfo{IP: "10.0.0.1"}})
+		put(c, "10.0.0.2", IPInfoResult{Error: &IPInfoError{IP: "10.0.0.2", ErrorMessage: "reserved"}})
+
+		now = now.Add(2 * time.Minute)
+
+		assert.True(t, cached(c, "10.0.0.1"))
+		assert.False(t, cached(c, "10.0.0.2"))
+	})
+
+	t.Run("can_disable_negative_caching", func(t *testing.T) {
+		c := newTestCache(CacheOptions{NegativeTTL: -1})
+
+		put(c, "10.0.0.1", IPInfoResult{Error: &IPInfoError{IP: "10.0.0.1", ErrorMessage: "reserved"}})
+
+		assert.False(t, cached(c, "10.0.0.1"))
+	})
+
+	t.Run("counts_hits_misses_and_coalesced_lookups", func(t *testing.T) {
+		c := newTestCache(CacheOptions{})
+		addr, _ := cacheKey("10.0.0.1")
+		_, _, f, leader := c.begin(addr)
+		_, _, waiting, follower := c.begin(addr)
+		c.finish(addr, f, IPInfoResult{Error: &IPInfoError{ErrorMessage: "reserved"}}, nil)
+		result, err := waiting.wait(t.Context())
+		cached(c, "10.0.0.1")
+
+		assert.True(t, leader)
+		assert.False(t, follower)
+		assert.NoError(t, err)
+		assert.Equal(t, "reserved", result.Error.ErrorMessage)
+		assert.Equal(t, CacheStats{Hits: 1, NegativeHits: 1, Misses: 1, Coalesced: 1, Entries: 1}, c.snapshot())
+		assert.InDelta(t, 2.0/3.0, c.snapshot().HitRate(), 0.001)
+	})
+}
+
+func TestLookupIPInfosWithCache(t *testing.T) {
+	n, attempts := bulkClient(t, func(int) int { return http.StatusOK })
+	n.cache = newCache(CacheOptions{})
+
+	_, err := n.GetIPInfos(context.Background(), []string{"1.1.1.1"})
+	require.NoError(t, err)
+	results, err := n.GetIPInfos(context.Background(), []string{"not-an-ip", "8.8.8.8", "1.1.1.1", "9.9.9.9"})
+	require.NoError(t, err)
+
+	// Fetched results land on their own index around the cached one
+	for i, ip := range []string{"not-an-ip", "8.8.8.8", "1.1.1.1", "9.9.9.9"} {
+		assert.Equal(t, ip, results[i].IP)
+		assert.Equal(t, ip, results[i].Info.IP)
+	}
+	assert.Equal(t, 2, attempts())
+}
@@ -0,0 +1,285 @@
+package netinfo
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hyphen/go-sdk/internal/client"
+)
+
+const (
+	defaultMiddlewareTimeout = 200 * time.Millisecond
+
+	// backgroundLookupTimeout bounds a lookup that outlives its request to
+	// fill the cache
+	backgroundLookupTimeout = 5 * time.Second
+)
+
+// MiddlewareOptions configures Middleware
+type MiddlewareOptions struct {
+	// TrustedProxies are the networks of proxies whose forwarding headers are
+	// believed. Forwarding headers are ignored unless the connection comes
+	// from one of them.
+	TrustedProxies []netip.Prefix
+
+	// ClientIPHeader is the one header the trusted proxies write the client
+	// address to, defaults to X-Forwarded-For. Other forwarding headers are
+	// ignored, since a proxy passes those on from the client unchanged. Set it
+	// to Forwarded or X-Real-IP only when the proxies write that header.
+	ClientIPHeader string
+
+	// Timeout is the longest a request waits for its lookup, defaults to
+	// 200ms. With WithCache a lookup that takes longer still completes in the
+	// background, so the next request from the IP is served from the cache.
+	Timeout time.Duration
+
+	// LookupPrivate also looks up private, loopback, link-local, carrier-grade
+	// NAT, documentation and other non-public addresses, which are skipped by
+	// default
+	LookupPrivate bool
+}
+
+// requestGeo is what Middleware stores in the request context
+type requestGeo struct {
+	ip   netip.Addr
+	info *IPInfo
+}
+
+type contextKey struct{}
+
+// ContextWithIPInfo returns a copy of ctx carrying a client IP and its
+// information, which may be nil, as Middleware stores them. It is useful
+// for testing handlers.
+func ContextWithIPInfo(ctx context.Context, ip netip.Addr, info *IPInfo) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestGeo{ip: ip, info: info})
+}
+
+// IPInfoFromContext returns the information Middleware found for the client
+// of a request. It returns false when the address was skipped or the lookup
+// failed or timed out.
+func IPInfoFromContext(ctx context.Context) (*IPInfo, bool) {
+	geo, ok := ctx.Value(contextKey{}).(requestGeo)
+	if !ok || geo.info == nil {
+		return nil, false
+	}
+	return geo.info, true
+}
+
+// ClientIPFromContext returns the client IP Middleware determined for a request
+func ClientIPFromContext(ctx context.Context) (netip.Addr, bool) {
+	geo, ok := ctx.Value(contextKey{}).(requestGeo)
+	if !ok || !geo.ip.IsValid() {
+		return netip.Addr{}, false
+	}
+	return geo.ip, true
+}
+
+// Middleware returns HTTP middleware that looks up the client IP of each
+// request and stores it, with its information, in the request context for
+// IPInfoFromContext and ClientIPFromContext. Requests are never failed: when
+// the lookup fails or takes longer than the timeout the request goes on
+// without information. Combine it with WithCache to avoid a lookup per
+// request.
+//
+//	n, _ := netinfo.New(netinfo.WithCache(netinfo.CacheOptions{}))
+//	handler := n.Middleware(&netinfo.MiddlewareOptions{
+//		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//	})(mux)
+func (n *NetInfo) Middleware(opts *MiddlewareOptions) func(http.Handler) http.Handler {
+	if opts == nil {
+		opts = &MiddlewareOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultMiddlewareTimeout
+	}
+	trusted := slices.Clone(opts.TrustedProxies)
+	header := opts.ClientIPHeader
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, ok := ClientIP(r, trusted, header)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var info *IPInfo
+			if opts.LookupPrivate || isPublic(ip) {
+				info = n.lookupForRequest(r.Context(), ip, timeout)
+			}
+			next.ServeHTTP(w, r.WithContext(ContextWithIPInfo(r.Context(), ip, info)))
+		})
+	}
+}
+
+// lookupForRequest looks up ip, waiting at most timeout
+func (n *NetInfo) lookupForRequest(ctx context.Context, ip netip.Addr, timeout time.Duration) *IPInfo {
+	if n.cache == nil {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := n.lookupIPInfo(ctx, ip.String())
+		return n.requestInfo(ip, result, err)
+	}
+
+	// Let a slow lookup finish after the request moves on, so its result is
+	// cached for the next request
+	done := make(chan *IPInfo, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundLookupTimeout)
+		defer cancel()
+		result, err := n.lookupIPInfo(ctx, ip.String())
+		done <- n.requestInfo(ip, result, err)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case info := <-done:
+		return info
+	case <-timer.C:
+		n.log().Debug("ip info lookup timed out", slog.String("ip", ip.String()))
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// requestInfo returns the information of a lookup result, logging failures.
+// They are not passed to the error handler, since a request without geo
+// information is not an error.
+func (n *NetInfo) requestInfo(ip netip.Addr, result IPInfoResult, err error) *IPInfo {
+	if err == nil {
+		err = result.Err()
+	}
+	if err != nil {
+		n.log().Debug("ip info lookup failed", slog.String("ip", ip.String()), slog.Any(client.LogKeyError, err))
+		return nil
+	}
+	info := *result.Info
+	return &info
+}
+
+// nonPublicPrefixes are special-purpose ranges that pass IsGlobalUnicast and
+// IsPrivate but never identify a client on the internet (RFC 6890)
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved
+	netip.MustParsePrefix("2001:2::/48"),     // Benchmarking
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+}
+
+// isPublic reports whether ip is worth looking up
+func isPublic(ip netip.Addr) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	return !slices.ContainsFunc(nonPublicPrefixes, func(prefix netip.Prefix) bool {
+		return prefix.Contains(ip)
+	})
+}
+
+// ClientIP returns the IP of the client that sent r. The connection's
+// address is used unless it belongs to a trusted proxy, in which case header
+// is consulted: X-Forwarded-For when it is empty, the RFC 7239 for= addresses
+// when it is Forwarded, and otherwise a comma separated list of addresses
+// such as X-Real-IP. Forwarding chains are read from the nearest hop outwards,
+// and the first address that is not a trusted proxy is the client.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix, header string) (netip.Addr, bool) {
+	remote, ok := parseHost(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !isTrusted(remote, trustedProxies) {
+		return remote, true
+	}
+
+	if header == "" {
+		header = "X-Forwarded-For"
+	}
+	var chain []netip.Addr
+	if http.CanonicalHeaderKey(header) == "Forwarded" {
+		chain, ok = forwardedFor(r.Header.Values(header))
+	} else {
+		chain, ok = splitForwardedFor(r.Header.Values(header))
+	}
+	if !ok || len(chain) == 0 {
+		return remote, true
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !isTrusted(chain[i], trustedProxies) {
+			return chain[i], true
+		}
+	}
+	// Every hop is a trusted proxy, so the farthest one is the client
+	return chain[0], true
+}
+
+// forwardedFor returns the for= addresses of Forwarded header values, from
+// the client to the nearest proxy. It fails if an address is unknown or
+// obfuscated, since the chain cannot be followed past it.
+func forwardedFor(values []string) ([]netip.Addr, bool) {
+	var chain []netip.Addr
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, node, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(key, "for") {
+					continue
+				}
+				ip, ok := parseHost(strings.Trim(node, `"`))
+				if !ok {
+					return nil, false
+				}
+				chain = append(chain, ip)
+			}
+		}
+	}
+	return chain, true
+}
+
+// splitForwardedFor returns the addresses of X-Forwarded-For style header
+// values, from the client to the nearest proxy
+func splitForwardedFor(values []string) ([]netip.Addr, bool) {
+	var chain []netip.Addr
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			ip, ok := parseHost(strings.TrimSpace(hop))
+			if !ok {
+				return nil, false
+			}
+			chain = append(chain, ip)
+		}
+	}
+	return chain, true
+}
+
+// parseHost parses an IP that may carry a port or IPv6 brackets, as in
+// 192.0.2.1:8080 or [2001:db8::1]:443, normalized like cache keys
+func parseHost(host string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(host); err == nil {
+		return addrPort.Addr().Unmap().WithZone(""), true
+	}
+	return cacheKey(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+}
+
+// isTrusted reports whether ip belongs to one of the trusted networks
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
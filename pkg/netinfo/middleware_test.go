@@ -0,0 +1,141 @@
+package netinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8:ffff::/48")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		headers    map[string]string
+		want       string
+	}{
+		{"uses_the_connection_address", "203.0.113.7:5123", "", nil, "203.0.113.7"},
+		{"ignores_headers_from_untrusted_connections", "203.0.113.7:5123", "", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"reads_x_forwarded_for_from_the_nearest_hop", "10.0.0.2:80", "", map[string]string{"X-Forwarded-For": "192.0.2.99, 198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"uses_the_farthest_hop_when_all_are_trusted", "10.0.0.2:80", "", map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.5"}, "10.1.1.1"},
+		{"ignores_a_spoofed_forwarded_header_by_default", "10.0.0.2:80", "", map[string]string{
+			"Forwarded":       "for=192.0.2.60",
+			"X-Forwarded-For": "198.51.100.1",
+		}, "198.51.100.1"},
+		{"ignores_a_spoofed_x_real_ip_by_default", "10.0.0.2:80", "", map[string]string{"X-Real-IP": "198.51.100.9"}, "10.0.0.2"},
+		{"reads_the_forwarded_header_when_selected", "10.0.0.2:80", "Forwarded", map[string]string{
+			"Forwarded":       `for=192.0.2.60;proto=https, for="[2001:db8:cafe::17]:4711";by=10.0.0.5`,
+			"X-Forwarded-For": "198.51.100.1",
+		}, "2001:db8:cafe::17"},
+		{"stops_at_an_obfuscated_forwarded_node", "10.0.0.2:80", "Forwarded", map[string]string{"Forwarded": "for=_hidden"}, "10.0.0.2"},
+		{"reads_x_real_ip_when_selected", "[2001:db8:ffff::1]:443", "X-Real-IP", map[string]string{
+			"X-Real-IP":       "198.51.100.9",
+			"X-Forwarded-For": "198.51.100.1",
+		}, "198.51.100.9"},
+		{"ignores_malformed_x_forwarded_for", "10.0.0.2:80", "", map[string]string{"X-Forwarded-For": "198.51.100.1, garbage"}, "10.0.0.2"},
+		{"unmaps_ipv4_mapped_addresses", "[::ffff:203.0.113.7]:5123", "", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			ip, ok := ClientIP(r, trusted, tt.header)
+
+			require.True(t, ok)
+			assert.Equal(t, netip.MustParseAddr(tt.want), ip)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var lookups atomic.Int32
+	delay := atomic.Int64{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		time.Sleep(time.Duration(delay.Load()))
+		json.NewEncoder(w).Encode(IPInfo{IP: r.URL.Path[len("/ip/"):], Location: Location{Country: "NL"}})
+	}))
+	t.Cleanup(api.Close)
+
+	serve := func(n *NetInfo, opts *MiddlewareOptions, remoteAddr string) (*IPInfo, netip.Addr) {
+		var info *IPInfo
+		var ip netip.Addr
+		handler := n.Middleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, _ = IPInfoFromContext(r.Context())
+			ip, _ = ClientIPFromContext(r.Context())
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return info, ip
+	}
+
+	t.Run("stores_the_ip_info_in_the_context", func(t *testing.T) {
+		n, err := New(WithAPIKey("theKey"), WithBaseURI(api.URL))
+		require.NoError(t, err)
+
+		info, ip := serve(n, nil, "9.9.9.9:5123")
+
+		require.NotNil(t, info)
+		assert.Equal(t, "NL", info.Location.Country)
+		assert.Equal(t, netip.MustParseAddr("9.9.9.9"), ip)
+	})
+
+	t.Run("skips_non_public_addresses", func(t *testing.T) {
+		n, err := New(WithAPIKey("theKey"), WithBaseURI(api.URL))
+		require.NoError(t, err)
+		before := lookups.Load()
+
+		for _, remoteAddr := range []string{
+			"127.0.0.1:80", "192.168.1.20:80", "[fe80::1]:80", "[fd00::1]:80",
+			"100.64.12.1:80", "198.18.0.1:80", "203.0.113.7:80", "[2001:db8::1]:80",
+		} {
+			info, ip := serve(n, nil, remoteAddr)
+			assert.Nil(t, info, remoteAddr)
+			assert.True(t, ip.IsValid(), remoteAddr)
+		}
+
+		assert.Equal(t, before, lookups.Load())
+	})
+
+	t.Run("does_not_wait_longer_than_the_timeout", func(t *testing.T) {
+		n, err := New(WithAPIKey("theKey"), WithBaseURI(api.URL), WithCache(CacheOptions{}))
+		require.NoError(t, err)
+		delay.Store(int64(100 * time.Millisecond))
+		t.Cleanup(func() { delay.Store(0) })
+
+		start := time.Now()
+		info, _ := serve(n, &MiddlewareOptions{Timeout: 10 * time.Millisecond}, "8.8.4.4:80")
+		elapsed := time.Since(start)
+
+		assert.Nil(t, info)
+		assert.Less(t, elapsed, 80*time.Millisecond)
+		assert.Eventually(t, func() bool { return n.CacheStats().Entries == 1 }, time.Second, 10*time.Millisecond,
+			"the lookup completes in the background")
+		info, _ = serve(n, &MiddlewareOptions{Timeout: 10 * time.Millisecond}, "8.8.4.4:80")
+		assert.NotNil(t, info)
+	})
+
+	t.Run("returns_nothing_outside_the_middleware", func(t *testing.T) {
+		_, ok := IPInfoFromContext(context.Background())
+		_, ipOK := ClientIPFromContext(context.Background())
+
+		assert.False(t, ok)
+		assert.False(t, ipOK)
+	})
+}
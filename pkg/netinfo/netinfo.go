@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -37,15 +38,93 @@ type IPInfoError struct {
 	ErrorMessage string `json:"errorMessage"`
 }
 
+func (e *IPInfoError) Error() string {
+	return fmt.Sprintf("ip info for %s: %s", e.IP, e.ErrorMessage)
+}
+
+// IPInfoResult is the outcome for one address of a bulk lookup. Exactly one
+// of Info and Error is set.
+type IPInfoResult struct {
+	IP    string       // The requested address
+	Info  *IPInfo      // The information found for the address
+	Error *IPInfoError // Why the address could not be looked up
+}
+
+// Err returns the lookup error for the address, or nil if it succeeded
+func (r IPInfoResult) Err() error {
+	if r.Error == nil {
+		return nil
+	}
+	return r.Error
+}
+
+// UnmarshalJSON decodes either an IPInfo or an IPInfoError, telling them
+// apart by the error type or message
+func (r *IPInfoResult) UnmarshalJSON(data []byte) error {
+	var probe struct {
+		IP           string `json:"ip"`
+		Type         string `json:"type"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+
+	*r = IPInfoResult{IP: probe.IP}
+	if probe.Type == "error" || probe.ErrorMessage != "" {
+		r.Error = &IPInfoError{IP: probe.IP, Type: probe.Type, ErrorMessage: probe.ErrorMessage}
+		return nil
+	}
+	r.Info = &IPInfo{}
+	return json.Unmarshal(data, r.Info)
+}
+
+// MarshalJSON encodes whichever of Info and Error is set
+func (r IPInfoResult) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(r.Error)
+	}
+	if r.Info != nil {
+		return json.Marshal(r.Info)
+	}
+	return json.Marshal(IPInfoError{IP: r.IP, Type: "error"})
+}
+
+// IPInfoResults are the results of a bulk lookup, in the order of the
+// requested addresses
+type IPInfoResults []IPInfoResult
+
+// Split separates the successful lookups from the failed ones, keeping the
+// order of each
+func (r IPInfoResults) Split() ([]IPInfo, []IPInfoError) {
+	var infos []IPInfo
+	var failures []IPInfoError
+	for _, result := range r {
+		if result.Error != nil {
+			failures = append(failures, *result.Error)
+		} else if result.Info != nil {
+			infos = append(infos, *result.Info)
+		}
+	}
+	return infos, failures
+}
+
 // IPInfosResponse represents the response for bulk IP info requests
 type IPInfosResponse struct {
-	Data []interface{} `json:"data"`
+	Data IPInfoResults `json:"data"`
 }
 
 // Options represents configuration options for the NetInfo client
 type Options struct {
-	APIKey  string
-	BaseURI string
+	APIKey          string
+	BaseURI         string
+	Logger          *slog.Logger
+	RateLimit       float64
+	RateLimitBurst  int
+	MaxInFlight     int
+	MaxResponseSize int64
+	HTTPClient      *http.Client
+	Cache           *CacheOptions
 }
 
 // Option is a functional option for configuring the NetInfo client
@@ -65,12 +144,62 @@ func WithBaseURI(uri string) Option {
 	}
 }
 
+// WithLogger sets the structured logger used for request and error events
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithRateLimit limits requests to rps per second with bursts of up to burst
+// requests. Calls block until capacity is available or the context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *Options) {
+		o.RateLimit = rps
+		o.RateLimitBurst = burst
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent requests
+func WithMaxInFlight(n int) Option {
+	return func(o *Options) {
+		o.MaxInFlight = n
+	}
+}
+
+// WithMaxResponseSize limits the decompressed size of response bodies. Zero
+// keeps the default limit of 32 MiB and a negative value disables the guard.
+func WithMaxResponseSize(n int64) Option {
+	return func(o *Options) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithHTTPClient sets the *http.Client used to send requests, for example to
+// install a custom transport
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = httpClient
+	}
+}
+
+// WithCache caches lookups in memory, keyed by the normalized IP. Concurrent
+// lookups of the same IP share one request, and addresses the service
+// rejects are cached for the shorter NegativeTTL.
+func WithCache(opts CacheOptions) Option {
+	return func(o *Options) {
+		o.Cache = &opts
+	}
+}
+
 // NetInfo is the client for geo information services
 type NetInfo struct {
 	apiKey       string
 	baseURI      string
 	client       *client.Client
 	errorHandler func(error)
+	logger       *slog.Logger
+	cache        *cache
 }
 
 // New creates a new NetInfo client with functional options
@@ -100,10 +229,22 @@ func New(options ...Option) (*NetInfo, error) {
 		baseURI = "https://net.info"
 	}
 
+	logger := client.ServiceLogger(opts.Logger, "netinfo")
+
 	n := &NetInfo{
 		apiKey:  apiKey,
 		baseURI: baseURI,
-		client:  client.NewClient(baseURI),
+		client: client.NewClient(baseURI,
+			client.WithLogger(logger),
+			client.WithRateLimit(opts.RateLimit, opts.RateLimitBurst),
+			client.WithMaxInFlight(opts.MaxInFlight),
+			client.WithMaxResponseSize(opts.MaxResponseSize),
+			client.WithHTTPClient(opts.HTTPClient),
+		),
+		logger: logger,
+	}
+	if opts.Cache != nil {
+		n.cache = newCache(*opts.Cache)
 	}
 
 	return n, nil
@@ -114,70 +255,118 @@ func (n *NetInfo) SetErrorHandler(handler func(error)) {
 	n.errorHandler = handler
 }
 
-// emitError calls the error handler if set
+// emitError logs the error and calls the error handler if set
 func (n *NetInfo) emitError(err error) {
+	n.log().Error("operation failed", slog.Any(client.LogKeyError, err))
 	if n.errorHandler != nil {
 		n.errorHandler(err)
 	}
 }
 
-// GetIPInfo fetches GeoIP information for a given IP address
+// log returns the configured logger, or a discarding logger when none is set
+func (n *NetInfo) log() *slog.Logger {
+	if n.logger == nil {
+		return client.DiscardLogger()
+	}
+	return n.logger
+}
+
+// GetIPInfo fetches GeoIP information for a given IP address. When the
+// service rejects the address the error wraps its *IPInfoError.
 func (n *NetInfo) GetIPInfo(ctx context.Context, ip string) (*IPInfo, error) {
+	result, err := n.lookupIPInfo(ctx, ip)
+	if err == nil && result.Error != nil {
+		err = fmt.Errorf("failed to fetch ip info: %w", result.Error)
+	}
+	if err != nil {
+		n.emitError(err)
+		return nil, err
+	}
+	info := *result.Info
+	return &info, nil
+}
+
+// fetchIPInfo requests one IP without reporting failures. An address the
+// service rejects is returned as a result with an IPInfoError.
+func (n *NetInfo) fetchIPInfo(ctx context.Context, ip string) (IPInfoResult, error) {
 	url := fmt.Sprintf("%s/ip/%s", strings.TrimSuffix(n.baseURI, "/"), ip)
 	headers := client.CreateHeaders(n.apiKey)
 
 	resp, err := n.client.Get(ctx, url, headers)
 	if err != nil {
-		err = fmt.Errorf("failed to fetch ip info: %w", err)
-		n.emitError(err)
-		return nil, err
+		return IPInfoResult{}, fmt.Errorf("failed to fetch ip info: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusBadRequest {
+		var infoErr IPInfoError
+		if json.Unmarshal(resp.Body, &infoErr) == nil && infoErr.ErrorMessage != "" {
+			return IPInfoResult{IP: ip, Error: &infoErr}, nil
+		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("failed to fetch ip info: HTTP %d: %s", resp.StatusCode, resp.Status)
-		n.emitError(err)
-		return nil, err
+		return IPInfoResult{}, fmt.Errorf("failed to fetch ip info: %w", &statusError{code: resp.StatusCode, status: resp.Status})
 	}
 
 	var ipInfo IPInfo
 	if err := json.Unmarshal(resp.Body, &ipInfo); err != nil {
-		err = fmt.Errorf("failed to unmarshal response: %w", err)
-		n.emitError(err)
-		return nil, err
+		return IPInfoResult{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return &ipInfo, nil
+	return IPInfoResult{IP: ip, Info: &ipInfo}, nil
 }
 
-// GetIPInfos fetches GeoIP information for multiple IP addresses
-func (n *NetInfo) GetIPInfos(ctx context.Context, ips []string) ([]interface{}, error) {
+// GetIPInfos fetches GeoIP information for multiple IP addresses in one
+// request. Each result holds either the information or the lookup error for
+// the address at the same index; only a failure of the whole request returns
+// an error. Use IPInfos or StreamIPInfos for more IPs than one request takes.
+//
+// The service answers in request order, so results are matched to addresses
+// by position and IPInfoResult.IP is always the address as requested. A
+// response with a different number of results cannot be matched and is an
+// error.
+func (n *NetInfo) GetIPInfos(ctx context.Context, ips []string) (IPInfoResults, error) {
 	if len(ips) == 0 {
 		err := fmt.Errorf("the provided IPs array is invalid. It should be a non-empty array of strings")
 		n.emitError(err)
 		return nil, err
 	}
 
+	results, err := n.lookupIPInfos(ctx, ips)
+	if err != nil {
+		n.emitError(err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// fetchIPInfos sends one bulk request without reporting failures. Results are
+// assigned to ips by index, overriding any address the service echoes, and a
+// count mismatch is an error rather than a guess.
+func (n *NetInfo) fetchIPInfos(ctx context.Context, ips []string) (IPInfoResults, error) {
 	url := fmt.Sprintf("%s/ip", strings.TrimSuffix(n.baseURI, "/"))
 	headers := client.CreateHeaders(n.apiKey)
 
-	resp, err := n.client.Post(ctx, url, ips, headers)
+	resp, err := n.client.Stream(ctx, http.MethodPost, url, ips, headers)
 	if err != nil {
-		err = fmt.Errorf("failed to fetch ip infos: %w", err)
-		n.emitError(err)
-		return nil, err
+		return nil, fmt.Errorf("failed to fetch ip infos: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("failed to fetch ip infos: HTTP %d: %s", resp.StatusCode, resp.Status)
-		n.emitError(err)
-		return nil, err
+		return nil, fmt.Errorf("failed to fetch ip infos: %w", &statusError{code: resp.StatusCode, status: resp.Status})
 	}
 
 	var response IPInfosResponse
-	if err := json.Unmarshal(resp.Body, &response); err != nil {
-		err = fmt.Errorf("failed to unmarshal response: %w", err)
-		n.emitError(err)
-		return nil, err
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(response.Data) != len(ips) {
+		return nil, fmt.Errorf("failed to fetch ip infos: got %d results for %d IPs", len(response.Data), len(ips))
+	}
+	for i := range response.Data {
+		response.Data[i].IP = ips[i]
 	}
 
 	return response.Data, nil
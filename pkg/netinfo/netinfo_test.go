@@ -0,0 +1,129 @@
+package netinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkServer answers bulk lookups with the given data and records the IPs
+// requested
+func bulkServer(t *testing.T, data string, requested *[]string) *NetInfo {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ips []string
+		json.NewDecoder(r.Body).Decode(&ips)
+		if requested != nil {
+			*requested = append(*requested, ips...)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":` + data + `}`))
+	}))
+	t.Cleanup(server.Close)
+
+	n, err := New(WithAPIKey("theKey"), WithBaseURI(server.URL))
+	require.NoError(t, err)
+	return n
+}
+
+func TestIPInfoResult(t *testing.T) {
+	t.Run("decodes_an_ip_info", func(t *testing.T) {
+		var result IPInfoResult
+
+		err := json.Unmarshal([]byte(`{"ip":"8.8.8.8","type":"ipv4","location":{"country":"US","city":"Mountain View"}}`), &result)
+
+		require.NoError(t, err)
+		assert.Nil(t, result.Error)
+		assert.NoError(t, result.Err())
+		assert.Equal(t, "8.8.8.8", result.IP)
+		assert.Equal(t, "Mountain View", result.Info.Location.City)
+	})
+
+	t.Run("decodes_an_error_by_type_or_message", func(t *testing.T) {
+		for _, data := range []string{
+			`{"ip":"10.0.0.1","type":"error","errorMessage":"private address"}`,
+			`{"ip":"10.0.0.1","errorMessage":"private address"}`,
+		} {
+			var result IPInfoResult
+
+			err := json.Unmarshal([]byte(data), &result)
+
+			require.NoError(t, err)
+			assert.Nil(t, result.Info, data)
+			assert.EqualError(t, result.Err(), "ip info for 10.0.0.1: private address", data)
+		}
+	})
+
+	t.Run("round_trips_through_json", func(t *testing.T) {
+		original := IPInfoResult{IP: "10.0.0.1", Error: &IPInfoError{IP: "10.0.0.1", Type: "error", ErrorMessage: "private address"}}
+
+		data, err := json.Marshal(original)
+		require.NoError(t, err)
+		var decoded IPInfoResult
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		assert.Equal(t, original, decoded)
+	})
+}
+
+func TestIPInfoResultsSplit(t *testing.T) {
+	results := IPInfoResults{
+		{IP: "8.8.8.8", Info: &IPInfo{IP: "8.8.8.8"}},
+		{IP: "10.0.0.1", Error: &IPInfoError{IP: "10.0.0.1", ErrorMessage: "private address"}},
+		{IP: "1.1.1.1", Info: &IPInfo{IP: "1.1.1.1"}},
+		{IP: "10.0.0.2", Error: &IPInfoError{IP: "10.0.0.2", ErrorMessage: "private address"}},
+		{IP: "unset"},
+	}
+
+	infos, failures := results.Split()
+
+	assert.Equal(t, []IPInfo{{IP: "8.8.8.8"}, {IP: "1.1.1.1"}}, infos)
+	require.Len(t, failures, 2)
+	assert.Equal(t, "10.0.0.1", failures[0].IP)
+	assert.Equal(t, "10.0.0.2", failures[1].IP)
+}
+
+func TestGetIPInfos(t *testing.T) {
+	t.Run("assigns_requested_ips_by_index", func(t *testing.T) {
+		var requested []string
+		// The service echoes a normalized address and leaves it out of errors
+		n := bulkServer(t, `[
+			{"ip":"2001:db8::1","type":"ipv6","location":{"country":"US"}},
+			{"type":"error","errorMessage":"invalid address"}
+		]`, &requested)
+
+		results, err := n.GetIPInfos(context.Background(), []string{"2001:0db8::0001", "not-an-ip"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2001:0db8::0001", "not-an-ip"}, requested)
+		require.Len(t, results, 2)
+		assert.Equal(t, "2001:0db8::0001", results[0].IP)
+		assert.Equal(t, "US", results[0].Info.Location.Country)
+		assert.Equal(t, "not-an-ip", results[1].IP)
+		assert.Equal(t, "invalid address", results[1].Error.ErrorMessage)
+	})
+
+	t.Run("fails_when_the_result_count_does_not_match", func(t *testing.T) {
+		n := bulkServer(t, `[{"ip":"8.8.8.8","type":"ipv4"}]`, nil)
+		var handled error
+		n.SetErrorHandler(func(err error) { handled = err })
+
+		results, err := n.GetIPInfos(context.Background(), []string{"8.8.8.8", "1.1.1.1"})
+
+		assert.Nil(t, results)
+		assert.EqualError(t, err, "failed to fetch ip infos: got 1 results for 2 IPs")
+		assert.Equal(t, err, handled)
+	})
+
+	t.Run("rejects_an_empty_list", func(t *testing.T) {
+		n := bulkServer(t, `[]`, nil)
+
+		_, err := n.GetIPInfos(context.Background(), nil)
+
+		assert.EqualError(t, err, "the provided IPs array is invalid. It should be a non-empty array of strings")
+	})
+}
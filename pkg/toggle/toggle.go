@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"os"
@@ -64,6 +65,12 @@ type Options struct {
 	DefaultContext      *Context
 	HorizonURLs         []string
 	DefaultTargetingKey string
+	Logger              *slog.Logger
+	RateLimit           float64
+	RateLimitBurst      int
+	MaxInFlight         int
+	MaxResponseSize     int64
+	HTTPClient          *http.Client
 }
 
 // Option is a functional option for configuring the Toggle client
@@ -111,6 +118,46 @@ func WithDefaultTargetingKey(key string) Option {
 	}
 }
 
+// WithLogger sets the structured logger used for request, failover and
+// fallback events
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithRateLimit limits requests to rps per second with bursts of up to burst
+// requests. Calls block until capacity is available or the context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(o *Options) {
+		o.RateLimit = rps
+		o.RateLimitBurst = burst
+	}
+}
+
+// WithMaxInFlight limits the number of concurrent requests
+func WithMaxInFlight(n int) Option {
+	return func(o *Options) {
+		o.MaxInFlight = n
+	}
+}
+
+// WithMaxResponseSize limits the decompressed size of response bodies. Zero
+// keeps the default limit of 32 MiB and a negative value disables the guard.
+func WithMaxResponseSize(n int64) Option {
+	return func(o *Options) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithHTTPClient sets the *http.Client used to send requests, for example to
+// install a custom transport
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *Options) {
+		o.HTTPClient = httpClient
+	}
+}
+
 // Toggle is the client for feature flag management
 type Toggle struct {
 	publicAPIKey        string
@@ -122,6 +169,7 @@ type Toggle struct {
 	defaultTargetingKey string
 	client              *client.Client
 	errorHandler        func(error)
+	logger              *slog.Logger
 }
 
 // New creates a new Toggle client with functional options
@@ -171,6 +219,8 @@ func New(options ...Option) (*Toggle, error) {
 		}
 	}
 
+	logger := client.ServiceLogger(opts.Logger, "toggle")
+
 	t := &Toggle{
 		publicAPIKey:        publicAPIKey,
 		organizationID:      organizationID,
@@ -179,7 +229,14 @@ func New(options ...Option) (*Toggle, error) {
 		horizonURLs:         horizonURLs,
 		defaultContext:      opts.DefaultContext,
 		defaultTargetingKey: defaultTargetingKey,
-		client:              client.NewClient(""),
+		client: client.NewClient("",
+			client.WithLogger(logger),
+			client.WithRateLimit(opts.RateLimit, opts.RateLimitBurst),
+			client.WithMaxInFlight(opts.MaxInFlight),
+			client.WithMaxResponseSize(opts.MaxResponseSize),
+			client.WithHTTPClient(opts.HTTPClient),
+		),
+		logger: logger,
 	}
 
 	return t, nil
@@ -190,38 +247,63 @@ func (t *Toggle) SetErrorHandler(handler func(error)) {
 	t.errorHandler = handler
 }
 
-// emitError calls the error handler if set
+// emitError logs the error and calls the error handler if set
 func (t *Toggle) emitError(err error) {
+	t.log().Error("operation failed", slog.Any(client.LogKeyError, err))
 	if t.errorHandler != nil {
 		t.errorHandler(err)
 	}
 }
 
+// log returns the configured logger, or a discarding logger when none is set
+func (t *Toggle) log() *slog.Logger {
+	if t.logger == nil {
+		return client.DiscardLogger()
+	}
+	return t.logger
+}
+
+// fallback logs that the default value is being returned for a toggle
+func (t *Toggle) fallback(ctx context.Context, toggleKey, reason string) {
+	t.log().WarnContext(ctx, "using default toggle value",
+		slog.String(client.LogKeyToggle, toggleKey),
+		slog.String("reason", reason),
+	)
+}
+
 // Get retrieves a toggle value with generic type support
 func (t *Toggle) Get(ctx context.Context, toggleKey string, defaultValue interface{}, contextOverride *Context) (interface{}, error) {
 	evalContext := t.buildEvaluationContext(contextOverride)
 
 	headers := client.CreateHeaders(t.publicAPIKey)
 
+	t.log().DebugContext(ctx, "evaluating toggle", slog.String(client.LogKeyToggle, toggleKey))
+
 	// Try each horizon URL in order
 	var lastErr error
 	for _, baseURL := range t.horizonURLs {
 		url := fmt.Sprintf("%s/toggle/evaluate", strings.TrimSuffix(baseURL, "/"))
 
-		resp, err := t.client.Post(ctx, url, evalContext, headers)
+		resp, err := t.client.Stream(ctx, http.MethodPost, url, evalContext, headers)
 		if err != nil {
 			lastErr = fmt.Errorf("request to %s failed: %w", baseURL, err)
+			t.failover(ctx, url, lastErr)
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+			t.failover(ctx, url, lastErr)
 			continue
 		}
 
 		var evalResp EvaluationResponse
-		if err := json.Unmarshal(resp.Body, &evalResp); err != nil {
+		err = json.NewDecoder(resp.Body).Decode(&evalResp)
+		resp.Body.Close()
+		if err != nil {
 			lastErr = fmt.Errorf("failed to unmarshal response: %w", err)
+			t.failover(ctx, url, lastErr)
 			continue
 		}
 
@@ -229,6 +311,7 @@ func (t *Toggle) Get(ctx context.Context, toggleKey string, defaultValue interfa
 			return toggle.Value, nil
 		}
 
+		t.fallback(ctx, toggleKey, "toggle not found in evaluation response")
 		return defaultValue, nil
 	}
 
@@ -237,10 +320,19 @@ func (t *Toggle) Get(ctx context.Context, toggleKey string, defaultValue interfa
 	return defaultValue, err
 }
 
+// failover logs that a horizon URL failed and the next one will be tried
+func (t *Toggle) failover(ctx context.Context, url string, err error) {
+	t.log().WarnContext(ctx, "horizon URL failed",
+		slog.String(client.LogKeyURL, client.RedactURL(url)),
+		slog.Any(client.LogKeyError, err),
+	)
+}
+
 // GetBoolean retrieves a boolean toggle value
 func (t *Toggle) GetBoolean(ctx context.Context, toggleKey string, defaultValue bool, contextOverride *Context) bool {
 	val, err := t.Get(ctx, toggleKey, defaultValue, contextOverride)
 	if err != nil {
+		t.fallback(ctx, toggleKey, "evaluation failed")
 		return defaultValue
 	}
 
@@ -248,6 +340,7 @@ func (t *Toggle) GetBoolean(ctx context.Context, toggleKey string, defaultValue
 		return boolVal
 	}
 
+	t.fallback(ctx, toggleKey, "unexpected value type")
 	return defaultValue
 }
 
@@ -255,6 +348,7 @@ func (t *Toggle) GetBoolean(ctx context.Context, toggleKey string, defaultValue
 func (t *Toggle) GetString(ctx context.Context, toggleKey string, defaultValue string, contextOverride *Context) string {
 	val, err := t.Get(ctx, toggleKey, defaultValue, contextOverride)
 	if err != nil {
+		t.fallback(ctx, toggleKey, "evaluation failed")
 		return defaultValue
 	}
 
@@ -262,6 +356,7 @@ func (t *Toggle) GetString(ctx context.Context, toggleKey string, defaultValue s
 		return strVal
 	}
 
+	t.fallback(ctx, toggleKey, "unexpected value type")
 	return defaultValue
 }
 
@@ -269,6 +364,7 @@ func (t *Toggle) GetString(ctx context.Context, toggleKey string, defaultValue s
 func (t *Toggle) GetNumber(ctx context.Context, toggleKey string, defaultValue float64, contextOverride *Context) float64 {
 	val, err := t.Get(ctx, toggleKey, defaultValue, contextOverride)
 	if err != nil {
+		t.fallback(ctx, toggleKey, "evaluation failed")
 		return defaultValue
 	}
 
@@ -276,6 +372,7 @@ func (t *Toggle) GetNumber(ctx context.Context, toggleKey string, defaultValue f
 		return numVal
 	}
 
+	t.fallback(ctx, toggleKey, "unexpected value type")
 	return defaultValue
 }
 
@@ -283,6 +380,7 @@ func (t *Toggle) GetNumber(ctx context.Context, toggleKey string, defaultValue f
 func (t *Toggle) GetObject(ctx context.Context, toggleKey string, defaultValue map[string]interface{}, contextOverride *Context) map[string]interface{} {
 	val, err := t.Get(ctx, toggleKey, defaultValue, contextOverride)
 	if err != nil {
+		t.fallback(ctx, toggleKey, "evaluation failed")
 		return defaultValue
 	}
 
@@ -290,6 +388,7 @@ func (t *Toggle) GetObject(ctx context.Context, toggleKey string, defaultValue m
 		return objVal
 	}
 
+	t.fallback(ctx, toggleKey, "unexpected value type")
 	return defaultValue
 }
 
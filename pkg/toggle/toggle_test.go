@@ -1,11 +1,16 @@
 package toggle
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -127,6 +132,92 @@ func TestGetBoolean(t *testing.T) {
 	})
 }
 
+func TestLogging(t *testing.T) {
+	t.Run("logs_failover_and_fallback_without_the_api_key", func(t *testing.T) {
+		thePublicAPIKey := "public_dGVzdC1vcmc6c2VjcmV0"
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(func() { server.Close() })
+
+		toggle, err := New(
+			WithPublicAPIKey(thePublicAPIKey),
+			WithApplicationID("anApplicationID"),
+			WithHorizonURLs([]string{server.URL}),
+			WithLogger(logger),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create toggle client: %v", err)
+		}
+
+		toggle.GetBoolean(context.Background(), "aToggleKey", false, nil)
+
+		output := logs.String()
+		for _, expected := range []string{
+			`"level":"DEBUG","msg":"sending request"`,
+			`"level":"WARN","msg":"horizon URL failed"`,
+			`"level":"ERROR","msg":"operation failed"`,
+			`"level":"WARN","msg":"using default toggle value"`,
+			`"service":"toggle"`,
+			`"toggle":"aToggleKey"`,
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected logs to contain %s, got %s", expected, output)
+			}
+		}
+		if strings.Contains(output, thePublicAPIKey) {
+			t.Errorf("Expected logs not to contain the API key, got %s", output)
+		}
+	})
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("limits_concurrent_requests", func(t *testing.T) {
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			json.NewEncoder(w).Encode(EvaluationResponse{})
+		}))
+		t.Cleanup(func() { server.Close() })
+
+		toggle, err := New(
+			WithPublicAPIKey("public_dGVzdC1vcmc6c2VjcmV0"),
+			WithApplicationID("anApplicationID"),
+			WithHorizonURLs([]string{server.URL}),
+			WithMaxInFlight(1),
+			WithRateLimit(1000, 4),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create toggle client: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				toggle.GetBoolean(context.Background(), "aToggleKey", false, nil)
+			}()
+		}
+		wg.Wait()
+
+		if maxInFlight != 1 {
+			t.Errorf("Expected at most 1 request in flight, got %d", maxInFlight)
+		}
+	})
+}
+
 func TestGetString(t *testing.T) {
 	t.Run("returns_the_expected_string_value_when_successful", func(t *testing.T) {
 		theToggleKey := "theToggleKey"
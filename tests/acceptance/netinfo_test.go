@@ -63,7 +63,12 @@ func TestNetInfoAcceptance(t *testing.T) {
 		results, err := client.GetIPInfos(ctx, ips)
 
 		require.NoError(t, err)
-		assert.Len(t, results, 2)
+		require.Len(t, results, 2)
+		for i, result := range results {
+			assert.NoError(t, result.Err())
+			assert.Equal(t, ips[i], result.IP)
+			assert.NotEmpty(t, result.Info.Location.Country)
+		}
 	})
 
 	t.Run("GetIPInfos_returns_error_for_empty_array", func(t *testing.T) {